@@ -6,7 +6,7 @@ import (
 	"os"
 	"time"
 
-	_ "github.com/hdecarne-github/fritzbox-telegraf-plugin/plugins/inputs/fritzbox"
+	"github.com/hdecarne-github/fritzbox-telegraf-plugin/plugins/inputs/fritzbox"
 
 	"github.com/influxdata/telegraf/plugins/common/shim"
 )
@@ -14,6 +14,7 @@ import (
 var pollInterval = flag.Duration("poll_interval", 1*time.Second, "how often to send metrics")
 var pollIntervalDisabled = flag.Bool("poll_interval_disabled", false, "set to true to disable polling. You want to use this when you are sending metrics on your own schedule")
 var configFile = flag.String("config", "", "path to the config file for this plugin")
+var meshTopology = flag.String("mesh_topology", "", "write the mesh topology of the configured mesh masters (dot or json) to stdout and exit")
 var err error
 
 // This is designed to be simple; Just change the import above and you're good.
@@ -51,6 +52,20 @@ func main() {
 		os.Exit(1)
 	}
 
+	// export the mesh topology instead of running the plugin (if requested)
+	if *meshTopology != "" {
+		plugin, ok := shimLayer.Input.(*fritzbox.FritzBox)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Err: no fritzbox input configured\n")
+			os.Exit(1)
+		}
+		if err := plugin.WriteMeshTopology(os.Stdout, *meshTopology); err != nil {
+			fmt.Fprintf(os.Stderr, "Err: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// run a single plugin until stdin closes or we receive a termination signal
 	if err := shimLayer.Run(*pollInterval); err != nil {
 		fmt.Fprintf(os.Stderr, "Err: %s\n", err)
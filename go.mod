@@ -8,6 +8,7 @@ require (
 	github.com/google/uuid v1.5.0
 	github.com/influxdata/telegraf v1.29.2
 	github.com/stretchr/testify v1.8.4
+	golang.org/x/crypto v0.18.0
 )
 
 require (
@@ -78,7 +79,6 @@ require (
 	github.com/tklauser/numcpus v0.7.0 // indirect
 	github.com/youmark/pkcs8 v0.0.0-20201027041543-1326539a0a0a // indirect
 	github.com/yusufpapurcu/wmi v1.2.3 // indirect
-	golang.org/x/crypto v0.18.0 // indirect
 	golang.org/x/exp v0.0.0-20240112132812-db7319d0e0e3 // indirect
 	golang.org/x/mod v0.14.0 // indirect
 	golang.org/x/oauth2 v0.15.0 // indirect
@@ -0,0 +1,216 @@
+// ahadevicelist.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"strconv"
+	"strings"
+)
+
+type ahaDeviceList struct {
+	Version   string      `xml:"version,attr"`
+	FwVersion string      `xml:"fwversion,attr"`
+	Devices   []ahaDevice `xml:"device"`
+	Groups    []ahaDevice `xml:"group"`
+}
+
+type ahaDevice struct {
+	Identifier      string                `xml:"identifier,attr"`
+	Id              string                `xml:"id,attr"`
+	FunctionBitmask int                   `xml:"functionbitmask,attr"`
+	FwVersion       string                `xml:"fwversion,attr"`
+	Manufacturer    string                `xml:"manufacturer,attr"`
+	ProductName     string                `xml:"productname,attr"`
+	Synchronized    string                `xml:"synchronized,attr"`
+	Present         int                   `xml:"present"`
+	TxBusy          string                `xml:"txbusy"`
+	Name            string                `xml:"name"`
+	Battery         string                `xml:"battery"`
+	BatteryLow      string                `xml:"batterylow"`
+	Switch          *ahaDeviceSwitch      `xml:"switch"`
+	PowerMeter      *ahaDevicePowerMeter  `xml:"powermeter"`
+	Temperature     *ahaDeviceTemperature `xml:"temperature"`
+	Humidity        *ahaDeviceHumidity    `xml:"humidity"`
+	Alerts          []ahaDeviceAlert      `xml:"alert"`
+	Buttons         []ahaDeviceButton     `xml:"button"`
+	Thermostat      *ahaDeviceThermostat  `xml:"hkr"`
+	SimpleOnOff     *ahaDeviceSimpleOnOff `xml:"simpleonoff"`
+	LevelControl    *ahaDeviceLevel       `xml:"levelcontrol"`
+	ColorControl    *ahaDeviceColor       `xml:"colorcontrol"`
+	Blind           *ahaDeviceBlind       `xml:"blind"`
+	GroupInfo       *ahaDeviceGroupInfo   `xml:"groupinfo"`
+}
+
+type ahaDeviceSwitch struct {
+	State      string `xml:"state"`
+	Mode       string `xml:"mode"`
+	Lock       string `xml:"lock"`
+	DeviceLock string `xml:"devicelock"`
+}
+
+type ahaDevicePowerMeter struct {
+	Voltage string `xml:"voltage"`
+	Power   string `xml:"power"`
+	Energy  string `xml:"energy"`
+}
+
+type ahaDeviceTemperature struct {
+	Celsius string `xml:"celsius"`
+	Offset  string `xml:"offset"`
+}
+
+type ahaDeviceHumidity struct {
+	RelHumidity string `xml:"rel_humidity"`
+}
+
+type ahaDeviceAlert struct {
+	State                 string `xml:"state"`
+	LastAlertChgTimestamp string `xml:"lastalertchgtimestamp"`
+}
+
+type ahaDeviceButton struct {
+	Identifier           string `xml:"identifier,attr"`
+	Id                   string `xml:"id,attr"`
+	Name                 string `xml:"name"`
+	LastPressedTimestamp string `xml:"lastpressedtimestamp"`
+}
+
+type ahaDeviceThermostat struct {
+	Tist                    string `xml:"tist"`
+	Tsoll                   string `xml:"tsoll"`
+	Absenk                  string `xml:"absenk"`
+	Komfort                 string `xml:"komfort"`
+	Lock                    string `xml:"lock"`
+	DeviceLock              string `xml:"devicelock"`
+	ErrorCode               string `xml:"errorcode"`
+	WindowOpenActive        string `xml:"windowopenactiv"`
+	BoostActive             string `xml:"boostactive"`
+	Battery                 string `xml:"battery"`
+	BatteryLow              string `xml:"batterylow"`
+	SummerActive            string `xml:"summeractive"`
+	HolidayActive           string `xml:"holidayactive"`
+	AdaptiveHeatingRunning  string `xml:"adaptiveHeatingRunning"`
+	AdaptiveHeatingActive   string `xml:"adaptiveHeatingActive"`
+	NextChangeEndPeriod     string `xml:"nextchange>endperiod"`
+	NextChangeTchange       string `xml:"nextchange>tchange"`
+	WindowOpenActiveEndTime string `xml:"windowopenactiveendtime"`
+	BoostActiveEndTime      string `xml:"boostactiveendtime"`
+}
+
+type ahaDeviceSimpleOnOff struct {
+	State string `xml:"state"`
+}
+
+type ahaDeviceLevel struct {
+	Level           string `xml:"level"`
+	LevelPercentage string `xml:"levelpercentage"`
+}
+
+type ahaDeviceColor struct {
+	SupportedModes   string `xml:"supported_modes,attr"`
+	CurrentMode      string `xml:"current_mode,attr"`
+	FullColorSupport string `xml:"fullcolorsupport,attr"`
+	Mapped           string `xml:"mapped,attr"`
+	Hue              string `xml:"hue"`
+	Saturation       string `xml:"saturation"`
+	UnmappedHue      string `xml:"unmapped_hue"`
+	UnmappedSat      string `xml:"unmapped_saturation"`
+	Temperature      string `xml:"temperature"`
+}
+
+type ahaDeviceBlind struct {
+	EndPositionsSet string `xml:"endpositionsset"`
+	Mode            string `xml:"mode"`
+}
+
+type ahaDeviceGroupInfo struct {
+	MasterDeviceId string `xml:"masterdeviceid"`
+	Members        string `xml:"members"`
+}
+
+func (device *ahaDevice) getAIN() string {
+	return strings.ReplaceAll(device.Identifier, " ", "")
+}
+
+func (groupInfo *ahaDeviceGroupInfo) getMembers() []string {
+	members := make([]string, 0)
+	for _, member := range strings.Split(groupInfo.Members, ",") {
+		member = strings.TrimSpace(member)
+		if member != "" {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+type ahaTemplateList struct {
+	Version   string        `xml:"version,attr"`
+	Templates []ahaTemplate `xml:"template"`
+}
+
+type ahaTemplate struct {
+	Identifier      string              `xml:"identifier,attr"`
+	Id              string              `xml:"id,attr"`
+	FunctionBitmask int                 `xml:"functionbitmask,attr"`
+	ApplyMask       int                 `xml:"applymask,attr"`
+	AutoCreate      string              `xml:"autocreate,attr"`
+	Name            string              `xml:"name"`
+	Devices         []ahaTemplateMember `xml:"devices>device"`
+	SubTemplates    []ahaTemplateMember `xml:"sub_templates>template"`
+}
+
+type ahaTemplateMember struct {
+	Identifier string `xml:"identifier,attr"`
+}
+
+// parseAHAInt parses an integer value as reported via the AHA interface. Empty values
+// as well as the value "inval" (used for currently unknown values) are reported as not found.
+func parseAHAInt(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "inval" {
+		return 0, false
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return parsed, true
+}
+
+func addAHAIntField(fields map[string]interface{}, key string, value string) {
+	parsed, found := parseAHAInt(value)
+	if found {
+		fields[key] = parsed
+	}
+}
+
+func addAHABoolField(fields map[string]interface{}, key string, value string) {
+	parsed, found := parseAHAInt(value)
+	if found {
+		fields[key] = parsed != 0
+	}
+}
+
+func addAHAScaledField(fields map[string]interface{}, key string, value string, scale float64) {
+	parsed, found := parseAHAInt(value)
+	if found {
+		fields[key] = float64(parsed) / scale
+	}
+}
+
+const ahaThermostatOff = 253
+const ahaThermostatOn = 254
+
+// addAHAThermostatField adds a thermostat temperature (reported in 0.5 °C steps). The special
+// values 253 (off) and 254 (on) are not reported.
+func addAHAThermostatField(fields map[string]interface{}, key string, value string) {
+	parsed, found := parseAHAInt(value)
+	if found && parsed < ahaThermostatOff {
+		fields[key] = float64(parsed) / 2
+	}
+}
@@ -0,0 +1,68 @@
+// ahadevicelist_test.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"encoding/xml"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testAHADeviceList1 = "testdata/ahadevicelist1.xml"
+const testAHATemplateList1 = "testdata/ahatemplatelist1.xml"
+
+func TestAHADeviceList1(t *testing.T) {
+	var deviceList ahaDeviceList
+
+	loadTestAHAXML(t, testAHADeviceList1, &deviceList)
+	require.Equal(t, "7.57", deviceList.FwVersion)
+	require.Equal(t, 7, len(deviceList.Devices))
+	require.Equal(t, 1, len(deviceList.Groups))
+	require.Equal(t, "116300123456", deviceList.Devices[0].getAIN())
+	require.NotNil(t, deviceList.Devices[0].PowerMeter)
+	require.Equal(t, "82150", deviceList.Devices[0].PowerMeter.Power)
+	require.Equal(t, 2, len(deviceList.Devices[3].Buttons))
+	require.Equal(t, "43", deviceList.Devices[3].Humidity.RelHumidity)
+	require.Equal(t, "2700", deviceList.Devices[4].ColorControl.Temperature)
+	require.Equal(t, "4", deviceList.Devices[4].ColorControl.CurrentMode)
+	require.NotNil(t, deviceList.Groups[0].GroupInfo)
+	require.Equal(t, []string{"17", "18"}, deviceList.Groups[0].GroupInfo.getMembers())
+}
+
+func TestAHATemplateList1(t *testing.T) {
+	var templateList ahaTemplateList
+
+	loadTestAHAXML(t, testAHATemplateList1, &templateList)
+	require.Equal(t, 1, len(templateList.Templates))
+	require.Equal(t, "Night", templateList.Templates[0].Name)
+	require.Equal(t, 129, templateList.Templates[0].ApplyMask)
+	require.Equal(t, 2, len(templateList.Templates[0].Devices))
+}
+
+func TestParseAHAInt(t *testing.T) {
+	value, found := parseAHAInt("42")
+	require.True(t, found)
+	require.Equal(t, 42, value)
+	_, found = parseAHAInt("inval")
+	require.False(t, found)
+	_, found = parseAHAInt("")
+	require.False(t, found)
+	fields := make(map[string]interface{})
+	addAHAThermostatField(fields, "target", "253")
+	addAHAThermostatField(fields, "current", "41")
+	require.Equal(t, map[string]interface{}{"current": 20.5}, fields)
+}
+
+func loadTestAHAXML(t *testing.T, filename string, v interface{}) {
+	xmlBytes, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	err = xml.Unmarshal(xmlBytes, v)
+	require.NoError(t, err)
+}
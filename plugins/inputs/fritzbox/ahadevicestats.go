@@ -0,0 +1,90 @@
+// ahadevicestats.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ahaDeviceStats struct {
+	Temperature []ahaDeviceStatsValues `xml:"temperature>stats"`
+	Humidity    []ahaDeviceStatsValues `xml:"humidity>stats"`
+	Voltage     []ahaDeviceStatsValues `xml:"voltage>stats"`
+	Power       []ahaDeviceStatsValues `xml:"power>stats"`
+	Energy      []ahaDeviceStatsValues `xml:"energy>stats"`
+}
+
+type ahaDeviceStatsValues struct {
+	Count    int    `xml:"count,attr"`
+	Grid     int    `xml:"grid,attr"`
+	DataTime int64  `xml:"datatime,attr"`
+	Values   string `xml:",chardata"`
+}
+
+type ahaDeviceStatsSeries struct {
+	statsType string
+	scale     float64
+	values    *ahaDeviceStatsValues
+}
+
+type ahaDeviceStatsSample struct {
+	timestamp time.Time
+	value     float64
+}
+
+// getSeries gets all reported stats series together with the scale of their values (temperature
+// in 0.1 °C, humidity in %, voltage in mV, power in 0.01 W and energy in Wh).
+func (stats *ahaDeviceStats) getSeries() []*ahaDeviceStatsSeries {
+	series := make([]*ahaDeviceStatsSeries, 0)
+	addSeries := func(statsType string, scale float64, values []ahaDeviceStatsValues) {
+		for valuesIndex := range values {
+			if values[valuesIndex].Grid > 0 {
+				series = append(series, &ahaDeviceStatsSeries{statsType: statsType, scale: scale, values: &values[valuesIndex]})
+			}
+		}
+	}
+	addSeries("temperature", 10, stats.Temperature)
+	addSeries("humidity", 1, stats.Humidity)
+	addSeries("voltage", 1000, stats.Voltage)
+	addSeries("power", 100, stats.Power)
+	addSeries("energy", 1, stats.Energy)
+	return series
+}
+
+func (series *ahaDeviceStatsSeries) getGrid() time.Duration {
+	return time.Duration(series.values.Grid) * time.Second
+}
+
+// getSamples converts the comma separated values (most recent value first) into timestamped
+// samples (oldest sample first). The timestamp of the most recent value is taken from the
+// datatime attribute. For older firmware versions not reporting the latter, the most recent
+// value is aligned to the grid before now. Unknown values ("-") as well as values not newer than
+// the given timestamp are skipped.
+func (series *ahaDeviceStatsSeries) getSamples(now time.Time, after time.Time) []*ahaDeviceStatsSample {
+	grid := series.getGrid()
+	latest := now.Truncate(grid)
+	if series.values.DataTime > 0 {
+		latest = time.Unix(series.values.DataTime, 0)
+	}
+	values := strings.Split(series.values.Values, ",")
+	samples := make([]*ahaDeviceStatsSample, 0, len(values))
+	for valueIndex := len(values) - 1; valueIndex >= 0; valueIndex-- {
+		timestamp := latest.Add(-time.Duration(valueIndex) * grid)
+		if !timestamp.After(after) {
+			continue
+		}
+		value, err := strconv.Atoi(strings.TrimSpace(values[valueIndex]))
+		if err != nil {
+			continue
+		}
+		samples = append(samples, &ahaDeviceStatsSample{timestamp: timestamp, value: float64(value) / series.scale})
+	}
+	return samples
+}
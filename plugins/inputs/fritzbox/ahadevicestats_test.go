@@ -0,0 +1,72 @@
+// ahadevicestats_test.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"encoding/xml"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testAHADeviceStats1 = "testdata/ahadevicestats1.xml"
+
+func TestAHADeviceStats1(t *testing.T) {
+	stats := loadTestAHADeviceStats(t, testAHADeviceStats1)
+	series := stats.getSeries()
+	require.Equal(t, 5, len(series))
+	latest := time.Unix(1706788800, 0)
+
+	temperature := series[0]
+	require.Equal(t, "temperature", temperature.statsType)
+	require.Equal(t, 15*time.Minute, temperature.getGrid())
+	samples := temperature.getSamples(latest, time.Time{})
+	require.Equal(t, 3, len(samples))
+	require.Equal(t, latest.Add(-45*time.Minute), samples[0].timestamp)
+	require.Equal(t, 23.5, samples[0].value)
+	require.Equal(t, latest, samples[2].timestamp)
+	require.Equal(t, 24.5, samples[2].value)
+
+	power := series[2]
+	require.Equal(t, "power", power.statsType)
+	samples = power.getSamples(latest, latest.Add(-15*time.Second))
+	require.Equal(t, 2, len(samples))
+	require.Equal(t, 81.9, samples[0].value)
+	require.Equal(t, 82.15, samples[1].value)
+
+	dailyEnergy := series[4]
+	require.Equal(t, "energy", dailyEnergy.statsType)
+	require.Equal(t, 24*time.Hour, dailyEnergy.getGrid())
+	samples = dailyEnergy.getSamples(latest, time.Time{})
+	require.Equal(t, 3, len(samples))
+	require.Equal(t, 1320.0, samples[0].value)
+	// Already reported samples are skipped
+	require.Equal(t, 0, len(dailyEnergy.getSamples(latest, latest)))
+}
+
+func TestAHADeviceStatsWithoutDataTime(t *testing.T) {
+	series := &ahaDeviceStatsSeries{statsType: "power", scale: 100, values: &ahaDeviceStatsValues{Count: 2, Grid: 10, Values: "100,200"}}
+	now := time.Date(2024, 2, 1, 12, 0, 3, 0, time.UTC)
+	samples := series.getSamples(now, time.Time{})
+	require.Equal(t, 2, len(samples))
+	require.Equal(t, time.Date(2024, 2, 1, 11, 59, 50, 0, time.UTC), samples[0].timestamp)
+	require.Equal(t, time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC), samples[1].timestamp)
+}
+
+func loadTestAHADeviceStats(t *testing.T, filename string) *ahaDeviceStats {
+	statsBytes, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	var stats ahaDeviceStats
+
+	err = xml.Unmarshal(statsBytes, &stats)
+	require.NoError(t, err)
+	return &stats
+}
@@ -0,0 +1,124 @@
+// devicelog.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const deviceLogTimeLayout = "02.01.06 15:04:05"
+
+type deviceLog struct {
+	Events []deviceLogEvent `xml:"Event"`
+}
+
+type deviceLogEvent struct {
+	Id    string `xml:"id"`
+	Group string `xml:"group"`
+	Date  string `xml:"date"`
+	Time  string `xml:"time"`
+	Msg   string `xml:"msg"`
+}
+
+type deviceLogEntry struct {
+	timestamp time.Time
+	category  string
+	messageId int
+	message   string
+}
+
+func (entry *deviceLogEntry) key() string {
+	return entry.category + "|" + strconv.Itoa(entry.messageId) + "|" + entry.message
+}
+
+func (log *deviceLog) getEntries(location *time.Location) []*deviceLogEntry {
+	entries := make([]*deviceLogEntry, 0, len(log.Events))
+	for _, event := range log.Events {
+		timestamp, err := time.ParseInLocation(deviceLogTimeLayout, strings.TrimSpace(event.Date)+" "+strings.TrimSpace(event.Time), location)
+		if err != nil {
+			continue
+		}
+		messageId, _ := strconv.Atoi(strings.TrimSpace(event.Id))
+		entries = append(entries, &deviceLogEntry{
+			timestamp: timestamp,
+			category:  strings.TrimSpace(event.Group),
+			messageId: messageId,
+			message:   strings.TrimSpace(event.Msg),
+		})
+	}
+	return entries
+}
+
+// parseDeviceLogText parses the plain text log as returned by GetDeviceLog (one
+// "dd.mm.yy hh:mm:ss message" line per entry). The plain text log provides neither category
+// nor message id.
+func parseDeviceLogText(text string, location *time.Location) []*deviceLogEntry {
+	entries := make([]*deviceLogEntry, 0)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) <= len(deviceLogTimeLayout) {
+			continue
+		}
+		timestamp, err := time.ParseInLocation(deviceLogTimeLayout, line[:len(deviceLogTimeLayout)], location)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, &deviceLogEntry{
+			timestamp: timestamp,
+			message:   strings.TrimSpace(line[len(deviceLogTimeLayout):]),
+		})
+	}
+	return entries
+}
+
+type deviceLogState struct {
+	latest     time.Time
+	latestKeys map[string]bool
+}
+
+// filter returns the entries not seen before (oldest entry first). As the log has a
+// resolution of one second, the entries of the latest second are remembered to detect
+// duplicates. Identical entries within the same second are numbered by their occurrence
+// to keep them apart.
+func (state *deviceLogState) filter(entries []*deviceLogEntry) []*deviceLogEntry {
+	sorted := make([]*deviceLogEntry, len(entries))
+	copy(sorted, entries)
+	// The device reports the most recent entry first
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].timestamp.Before(sorted[j].timestamp)
+	})
+	newEntries := make([]*deviceLogEntry, 0)
+	var occurrencesTimestamp time.Time
+	var occurrences map[string]int
+	for _, entry := range sorted {
+		if entry.timestamp.Before(state.latest) {
+			continue
+		}
+		if entry.timestamp.After(state.latest) {
+			state.latest = entry.timestamp
+			state.latestKeys = make(map[string]bool)
+		}
+		if occurrences == nil || !entry.timestamp.Equal(occurrencesTimestamp) {
+			occurrencesTimestamp = entry.timestamp
+			occurrences = make(map[string]int)
+		}
+		key := entry.key()
+		occurrence := occurrences[key]
+		occurrences[key]++
+		key += "|" + strconv.Itoa(occurrence)
+		if state.latestKeys[key] {
+			continue
+		}
+		state.latestKeys[key] = true
+		newEntries = append(newEntries, entry)
+	}
+	return newEntries
+}
@@ -0,0 +1,86 @@
+// devicelog_test.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"encoding/xml"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDeviceLog1 = "testdata/devicelog1.xml"
+const testDeviceLog2 = "testdata/devicelog2.xml"
+
+func TestDeviceLog1(t *testing.T) {
+	entries := loadTestDeviceLog(t, testDeviceLog1).getEntries(time.UTC)
+	require.Equal(t, 5, len(entries))
+	require.Equal(t, time.Date(2024, 2, 1, 12, 5, 10, 0, time.UTC), entries[0].timestamp)
+	require.Equal(t, "net", entries[0].category)
+	require.Equal(t, 119, entries[0].messageId)
+	require.Equal(t, "dsl", entries[4].category)
+	require.Equal(t, 22, entries[4].messageId)
+}
+
+func TestParseDeviceLogText(t *testing.T) {
+	entries := parseDeviceLogText("01.02.24 12:05:10 Anmeldung des Benutzers admin.\n01.02.24 11:58:42 DSL-Synchronisierung besteht.\ninvalid line\n", time.UTC)
+	require.Equal(t, 2, len(entries))
+	require.Equal(t, time.Date(2024, 2, 1, 11, 58, 42, 0, time.UTC), entries[1].timestamp)
+	require.Equal(t, "DSL-Synchronisierung besteht.", entries[1].message)
+	require.Equal(t, "", entries[1].category)
+}
+
+func TestDeviceLogStateFilter(t *testing.T) {
+	entries := loadTestDeviceLog(t, testDeviceLog1).getEntries(time.UTC)
+	var state deviceLogState
+
+	newEntries := state.filter(entries[1:])
+	require.Equal(t, 4, len(newEntries))
+	require.Equal(t, 22, newEntries[0].messageId)
+	require.Equal(t, 84, newEntries[3].messageId)
+	newEntries = state.filter(entries)
+	require.Equal(t, 1, len(newEntries))
+	require.Equal(t, 119, newEntries[0].messageId)
+	newEntries = state.filter(entries)
+	require.Equal(t, 0, len(newEntries))
+	// Additional entry within an already reported second
+	additional := &deviceLogEntry{timestamp: entries[0].timestamp, category: "sys", messageId: 1, message: "Additional"}
+	newEntries = state.filter(append([]*deviceLogEntry{additional}, entries...))
+	require.Equal(t, 1, len(newEntries))
+	require.Equal(t, "Additional", newEntries[0].message)
+}
+
+func TestDeviceLogStateFilterIdentical(t *testing.T) {
+	var state deviceLogState
+
+	state.filter(loadTestDeviceLog(t, testDeviceLog1).getEntries(time.UTC))
+	entries := loadTestDeviceLog(t, testDeviceLog2).getEntries(time.UTC)
+	newEntries := state.filter(entries)
+	require.Equal(t, 3, len(newEntries))
+	require.Equal(t, newEntries[0].key(), newEntries[1].key())
+	newEntries = state.filter(entries)
+	require.Equal(t, 0, len(newEntries))
+	// Another identical entry within the latest second
+	latest := &deviceLogEntry{timestamp: entries[0].timestamp, category: entries[0].category, messageId: entries[0].messageId, message: entries[0].message}
+	newEntries = state.filter(append([]*deviceLogEntry{latest}, entries...))
+	require.Equal(t, 1, len(newEntries))
+	require.Equal(t, 24, newEntries[0].messageId)
+}
+
+func loadTestDeviceLog(t *testing.T, filename string) *deviceLog {
+	deviceLogBytes, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	var deviceLog deviceLog
+
+	err = xml.Unmarshal(deviceLogBytes, &deviceLog)
+	require.NoError(t, err)
+	return &deviceLog
+}
@@ -0,0 +1,141 @@
+// docsisinfo.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+type docsisInfo struct {
+	SID  string `json:"sid"`
+	Data struct {
+		ChannelDs docsisChannels `json:"channelDs"`
+		ChannelUs docsisChannels `json:"channelUs"`
+	} `json:"data"`
+}
+
+type docsisChannels struct {
+	Docsis30 []docsisChannel `json:"docsis30"`
+	Docsis31 []docsisChannel `json:"docsis31"`
+}
+
+type docsisChannel struct {
+	ChannelID     docsisValue `json:"channelID"`
+	Channel       docsisValue `json:"channel"`
+	Frequency     docsisValue `json:"frequency"`
+	Type          docsisValue `json:"type"`
+	Modulation    docsisValue `json:"modulation"`
+	Multiplex     docsisValue `json:"multiplex"`
+	PowerLevel    docsisValue `json:"powerLevel"`
+	MSE           docsisValue `json:"mse"`
+	MER           docsisValue `json:"mer"`
+	Latency       docsisValue `json:"latency"`
+	CorrErrors    docsisValue `json:"corrErrors"`
+	NonCorrErrors docsisValue `json:"nonCorrErrors"`
+}
+
+// docsisValue accepts numbers as well as strings, as the web interface reports numeric values
+// either way (depending on the firmware version).
+type docsisValue string
+
+func (value *docsisValue) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*value = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var decoded string
+		err := json.Unmarshal(data, &decoded)
+		if err != nil {
+			return err
+		}
+		*value = docsisValue(strings.TrimSpace(decoded))
+		return nil
+	}
+	*value = docsisValue(data)
+	return nil
+}
+
+func (value docsisValue) float() (float64, bool) {
+	parsed, err := strconv.ParseFloat(string(value), 64)
+	return parsed, err == nil
+}
+
+func (value docsisValue) int() (int64, bool) {
+	parsed, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		floatValue, floatFound := value.float()
+		return int64(floatValue), floatFound
+	}
+	return parsed, true
+}
+
+// frequencyRange parses the channel's frequency (in MHz). DOCSIS 3.1 channels report a
+// frequency range (e.g. "751-861").
+func (value docsisValue) frequencyRange() (float64, float64, bool) {
+	start, end, isRange := strings.Cut(string(value), "-")
+	startFrequency, startFound := docsisValue(strings.TrimSpace(start)).float()
+	if !startFound {
+		return 0, 0, false
+	}
+	if !isRange {
+		return startFrequency, startFrequency, true
+	}
+	endFrequency, endFound := docsisValue(strings.TrimSpace(end)).float()
+	if !endFound {
+		return startFrequency, startFrequency, true
+	}
+	return startFrequency, endFrequency, true
+}
+
+func (channel *docsisChannel) fields() map[string]interface{} {
+	fields := make(map[string]interface{})
+	addDOCSISIntField(fields, "channel_id", channel.ChannelID)
+	addDOCSISIntField(fields, "channel", channel.Channel)
+	frequencyStart, frequencyEnd, frequencyFound := channel.Frequency.frequencyRange()
+	if frequencyFound {
+		fields["frequency"] = frequencyStart
+		if frequencyEnd != frequencyStart {
+			fields["frequency_end"] = frequencyEnd
+		}
+	}
+	modulation := channel.Modulation
+	if modulation == "" {
+		modulation = channel.Type
+	}
+	if modulation != "" {
+		fields["modulation"] = string(modulation)
+	}
+	if channel.Multiplex != "" {
+		fields["multiplex"] = string(channel.Multiplex)
+	}
+	addDOCSISFloatField(fields, "power_level", channel.PowerLevel)
+	addDOCSISFloatField(fields, "mse", channel.MSE)
+	addDOCSISFloatField(fields, "mer", channel.MER)
+	addDOCSISFloatField(fields, "latency", channel.Latency)
+	addDOCSISIntField(fields, "corrected_errors", channel.CorrErrors)
+	addDOCSISIntField(fields, "uncorrectable_errors", channel.NonCorrErrors)
+	return fields
+}
+
+func addDOCSISIntField(fields map[string]interface{}, key string, value docsisValue) {
+	parsed, found := value.int()
+	if found {
+		fields[key] = parsed
+	}
+}
+
+func addDOCSISFloatField(fields map[string]interface{}, key string, value docsisValue) {
+	parsed, found := value.float()
+	if found {
+		fields[key] = parsed
+	}
+}
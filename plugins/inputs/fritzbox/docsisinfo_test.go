@@ -0,0 +1,66 @@
+// docsisinfo_test.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDOCSISInfo1 = "testdata/docsisinfo1.json"
+
+func TestDOCSISInfo1(t *testing.T) {
+	info := loadTestDOCSISInfo(t, testDOCSISInfo1)
+	require.Equal(t, 2, len(info.Data.ChannelDs.Docsis30))
+	require.Equal(t, 1, len(info.Data.ChannelDs.Docsis31))
+	require.Equal(t, 2, len(info.Data.ChannelUs.Docsis30))
+	require.Equal(t, 0, len(info.Data.ChannelUs.Docsis31))
+	downstream30 := info.Data.ChannelDs.Docsis30[0].fields()
+	require.Equal(t, int64(7), downstream30["channel_id"])
+	require.Equal(t, 538.0, downstream30["frequency"])
+	require.Equal(t, "256QAM", downstream30["modulation"])
+	require.Equal(t, 4.3, downstream30["power_level"])
+	require.Equal(t, -37.6, downstream30["mse"])
+	require.Equal(t, int64(12), downstream30["corrected_errors"])
+	require.Equal(t, int64(0), downstream30["uncorrectable_errors"])
+	downstream31 := info.Data.ChannelDs.Docsis31[0].fields()
+	require.Equal(t, 751.0, downstream31["frequency"])
+	require.Equal(t, 861.0, downstream31["frequency_end"])
+	require.Equal(t, 40.2, downstream31["mer"])
+	require.Equal(t, int64(1234), downstream31["corrected_errors"])
+	upstream30 := info.Data.ChannelUs.Docsis30[1].fields()
+	require.Equal(t, 44.6, upstream30["frequency"])
+	require.Equal(t, "ATDMA", upstream30["multiplex"])
+	require.NotContains(t, upstream30, "corrected_errors")
+}
+
+func TestDOCSISValue(t *testing.T) {
+	var values []docsisValue
+
+	require.NoError(t, json.Unmarshal([]byte(`["1.5", 2, null, " 3 "]`), &values))
+	require.Equal(t, []docsisValue{"1.5", "2", "", "3"}, values)
+	_, found := values[2].float()
+	require.False(t, found)
+	intValue, found := values[0].int()
+	require.True(t, found)
+	require.Equal(t, int64(1), intValue)
+}
+
+func loadTestDOCSISInfo(t *testing.T, filename string) *docsisInfo {
+	infoBytes, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	var info docsisInfo
+
+	err = json.Unmarshal(infoBytes, &info)
+	require.NoError(t, err)
+	return &info
+}
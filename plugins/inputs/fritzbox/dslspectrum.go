@@ -0,0 +1,86 @@
+// dslspectrum.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+type dslSpectrum struct {
+	SID  string `json:"sid"`
+	Data struct {
+		Ports []dslSpectrumPort `json:"portRes"`
+	} `json:"data"`
+}
+
+type dslSpectrumPort struct {
+	ActualData struct {
+		BitValues        []int `json:"BIT_VALUES"`
+		SNRValues        []int `json:"SNR_VALUES"`
+		TonesPerBitValue int   `json:"TONES_PER_BAT_VALUE"`
+		TonesPerSNRValue int   `json:"TONES_PER_SNR_VALUE"`
+	} `json:"actualData"`
+}
+
+type dslSpectrumBand struct {
+	toneStart int
+	toneEnd   int
+	bits      dslSpectrumValues
+	snr       dslSpectrumValues
+}
+
+type dslSpectrumValues struct {
+	count int
+	sum   int
+	min   int
+	max   int
+}
+
+func (values *dslSpectrumValues) add(value int) {
+	if values.count == 0 || value < values.min {
+		values.min = value
+	}
+	if values.count == 0 || value > values.max {
+		values.max = value
+	}
+	values.count++
+	values.sum += value
+}
+
+func (values *dslSpectrumValues) avg() float64 {
+	if values.count == 0 {
+		return 0
+	}
+	return float64(values.sum) / float64(values.count)
+}
+
+func (port *dslSpectrumPort) tones() int {
+	bitTones := len(port.ActualData.BitValues) * max(port.ActualData.TonesPerBitValue, 1)
+	snrTones := len(port.ActualData.SNRValues) * max(port.ActualData.TonesPerSNRValue, 1)
+	return max(bitTones, snrTones)
+}
+
+func (port *dslSpectrumPort) getBands(bandCount int) []*dslSpectrumBand {
+	tones := port.tones()
+	if tones == 0 {
+		return []*dslSpectrumBand{}
+	}
+	bandCount = max(min(bandCount, tones), 1)
+	bands := make([]*dslSpectrumBand, bandCount)
+	for bandIndex := range bands {
+		bands[bandIndex] = &dslSpectrumBand{
+			toneStart: bandIndex * tones / bandCount,
+			toneEnd:   (bandIndex+1)*tones/bandCount - 1,
+		}
+	}
+	tonesPerBitValue := max(port.ActualData.TonesPerBitValue, 1)
+	for valueIndex, value := range port.ActualData.BitValues {
+		bands[valueIndex*tonesPerBitValue*bandCount/tones].bits.add(value)
+	}
+	tonesPerSNRValue := max(port.ActualData.TonesPerSNRValue, 1)
+	for valueIndex, value := range port.ActualData.SNRValues {
+		bands[valueIndex*tonesPerSNRValue*bandCount/tones].snr.add(value)
+	}
+	return bands
+}
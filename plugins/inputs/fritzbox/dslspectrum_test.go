@@ -0,0 +1,58 @@
+// dslspectrum_test.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDSLSpectrum1 = "testdata/dslspectrum1.json"
+
+func TestDSLSpectrum1(t *testing.T) {
+	spectrum := loadTestDSLSpectrum(t, testDSLSpectrum1)
+	require.Equal(t, "9f46d0308fd4fdd9", spectrum.SID)
+	require.Equal(t, 1, len(spectrum.Data.Ports))
+	port := &spectrum.Data.Ports[0]
+	require.Equal(t, 512, port.tones())
+	bands := port.getBands(8)
+	require.Equal(t, 8, len(bands))
+	require.Equal(t, 64, bands[1].toneStart)
+	require.Equal(t, 127, bands[1].toneEnd)
+	require.Equal(t, 8, bands[1].bits.count)
+	require.Equal(t, 9.5, bands[1].bits.avg())
+	require.Equal(t, 6, bands[1].bits.min)
+	require.Equal(t, 13, bands[1].bits.max)
+	require.Equal(t, 16, bands[1].snr.count)
+	require.Equal(t, 43, bands[1].snr.min)
+	require.Equal(t, 48, bands[1].snr.max)
+	require.Equal(t, 511, bands[7].toneEnd)
+	require.Equal(t, 0, bands[7].bits.min)
+}
+
+func TestDSLSpectrumBands(t *testing.T) {
+	spectrum := loadTestDSLSpectrum(t, testDSLSpectrum1)
+	port := &spectrum.Data.Ports[0]
+	require.Equal(t, 1, len(port.getBands(0)))
+	require.Equal(t, 512, len(port.getBands(1000)))
+	require.Equal(t, 0, len((&dslSpectrumPort{}).getBands(32)))
+}
+
+func loadTestDSLSpectrum(t *testing.T, filename string) *dslSpectrum {
+	spectrumBytes, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	var spectrum dslSpectrum
+
+	err = json.Unmarshal(spectrumBytes, &spectrum)
+	require.NoError(t, err)
+	return &spectrum
+}
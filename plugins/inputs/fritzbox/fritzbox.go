@@ -17,12 +17,19 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -33,6 +40,40 @@ type deviceInfo struct {
 	GetMeshInfo          bool
 	ServiceInfo          *tr64Desc
 	cachedAuthentication [2]string
+	meshClients          map[string]*meshClientState
+	meshLinks            map[string]*meshLinkHistory
+	wlanStates           map[string]*wlanState
+	meshSchemaWarned     string
+	webSession           *webSession
+	onlineMonitorStates  map[string]*onlineMonitorState
+	deviceClock          *deviceClock
+	deviceServiceInfo    *deviceInfoServiceInfo
+	wanCounters          *wanCounterSample
+	wanAccessType        string
+	deviceLogState       *deviceLogState
+	uptimeSamples        map[string]*uptimeSample
+	ahaStatsLatest       map[string]time.Time
+	connectionUptime     *uptimeSample
+	connectionResets     uint
+}
+
+// deviceClock caches the device's clock as reported by the Time service
+type deviceClock struct {
+	offset   time.Duration
+	location *time.Location
+	err      error
+}
+
+type meshClientState struct {
+	name  string
+	peer  string
+	link  string
+	since time.Time
+}
+
+type wlanState struct {
+	enabled bool
+	since   time.Time
 }
 
 type tr64Desc struct {
@@ -53,48 +94,146 @@ type tr64DescDeviceService struct {
 	ControlURL  string `xml:"controlURL"`
 }
 
+func (d *tr64Desc) findService(serviceTypePrefix string) *tr64DescDeviceService {
+	service := findService(d.Services, serviceTypePrefix)
+	if service == nil {
+		service = findDeviceService(d.Devices, serviceTypePrefix)
+	}
+	return service
+}
+
+func findDeviceService(devices []tr64DescDevice, serviceTypePrefix string) *tr64DescDeviceService {
+	for _, device := range devices {
+		service := findService(device.Services, serviceTypePrefix)
+		if service == nil {
+			service = findDeviceService(device.Devices, serviceTypePrefix)
+		}
+		if service != nil {
+			return service
+		}
+	}
+	return nil
+}
+
+func findService(services []tr64DescDeviceService, serviceTypePrefix string) *tr64DescDeviceService {
+	for serviceIndex, service := range services {
+		if strings.HasPrefix(service.ServiceType, serviceTypePrefix) {
+			return &services[serviceIndex]
+		}
+	}
+	return nil
+}
+
 func (s *tr64DescDeviceService) ShortServiceId() string {
 	split := strings.Split(s.ServiceId, ":")
 	return split[len(split)-1]
 }
 
 type FritzBox struct {
-	Devices         [][]string `toml:"devices"`
-	Timeout         int        `toml:"timeout"`
-	TLSSkipVerify   bool       `toml:"tls_skip_verify"`
-	GetDeviceInfo   bool       `toml:"get_device_info"`
-	GetWLANInfo     bool       `toml:"get_wlan_info"`
-	GetWANInfo      bool       `toml:"get_wan_info"`
-	GetDSLInfo      bool       `toml:"get_dsl_info"`
-	GetPPPInfo      bool       `toml:"get_ppp_info"`
-	GetMeshInfo     []string   `toml:"get_mesh_info"`
-	GetMeshClients  bool       `toml:"get_mesh_clients"`
-	MeshClientTypes []string   `toml:"mesh_client_types"`
-	FullQueryCycle  int        `toml:"full_query_cycle"`
-	Debug           bool       `toml:"debug"`
+	Devices                    [][]string          `toml:"devices"`
+	Timeout                    int                 `toml:"timeout"`
+	TLSSkipVerify              bool                `toml:"tls_skip_verify"`
+	GetDeviceInfo              bool                `toml:"get_device_info"`
+	GetFirmwareInfo            bool                `toml:"get_firmware_info"`
+	GetDeviceLog               bool                `toml:"get_device_log"`
+	DeviceLogBacklog           bool                `toml:"device_log_backlog"`
+	GetTimeInfo                bool                `toml:"get_time_info"`
+	GetWLANInfo                bool                `toml:"get_wlan_info"`
+	GetWLANRadioInfo           bool                `toml:"get_wlan_radio_info"`
+	GetWLANNeighbours          bool                `toml:"get_wlan_neighbours"`
+	GetWLANState               bool                `toml:"get_wlan_state"`
+	GetLANInfo                 bool                `toml:"get_lan_info"`
+	GetWANInfo                 bool                `toml:"get_wan_info"`
+	GetOnlineMonitor           bool                `toml:"get_online_monitor"`
+	GetDOCSISInfo              bool                `toml:"get_docsis_info"`
+	GetDSLInfo                 bool                `toml:"get_dsl_info"`
+	GetDSLIntervalStatistics   bool                `toml:"get_dsl_interval_statistics"`
+	GetDSLSpectrum             bool                `toml:"get_dsl_spectrum"`
+	DSLSpectrumBands           int                 `toml:"dsl_spectrum_bands"`
+	GetPPPInfo                 bool                `toml:"get_ppp_info"`
+	GetAHAInfo                 bool                `toml:"get_aha_info"`
+	GetAHAStats                bool                `toml:"get_aha_stats"`
+	GetMeshInfo                []string            `toml:"get_mesh_info"`
+	GetMeshClients             bool                `toml:"get_mesh_clients"`
+	GetMeshClientEvents        bool                `toml:"get_mesh_client_events"`
+	GetMeshNodes               bool                `toml:"get_mesh_nodes"`
+	MeshClientTypes            []string            `toml:"mesh_client_types"`
+	MeshClientNameInclude      []string            `toml:"mesh_client_name_include"`
+	MeshClientNameExclude      []string            `toml:"mesh_client_name_exclude"`
+	MeshClientMACInclude       []string            `toml:"mesh_client_mac_include"`
+	MeshClientMACExclude       []string            `toml:"mesh_client_mac_exclude"`
+	MeshClientInterfaceInclude []string            `toml:"mesh_client_interface_include"`
+	MeshClientInterfaceExclude []string            `toml:"mesh_client_interface_exclude"`
+	MeshClientReportUnnamed    bool                `toml:"mesh_client_report_unnamed"`
+	DiscoverMeshSlaves         bool                `toml:"discover_mesh_slaves"`
+	MeshSlaveCredentials       map[string][]string `toml:"mesh_slave_credentials"`
+	MeshTopologyDOTFile        string              `toml:"mesh_topology_dot_file"`
+	MeshTopologyJSONFile       string              `toml:"mesh_topology_json_file"`
+	MeshLinkWindow             int                 `toml:"mesh_link_window"`
+	MeshLinkDegradedRatio      float64             `toml:"mesh_link_degraded_ratio"`
+	MeshLinkDegradedCycles     int                 `toml:"mesh_link_degraded_cycles"`
+	FullQueryCycle             int                 `toml:"full_query_cycle"`
+	Debug                      bool                `toml:"debug"`
 
 	Log telegraf.Logger
 
-	deviceInfos  map[string]*deviceInfo
-	cachedClient *http.Client
-	queryCounter int
+	deviceInfos       map[string]*deviceInfo
+	discoveredDevices map[string][][]string
+	meshTopologies    map[string]*meshTopology
+	meshClientFilter  *meshClientFilter
+	configuredHosts   map[string]bool
+	lookupHost        func(host string) ([]string, error)
+	cachedClient      *http.Client
+	queryCounter      int
 }
 
 func NewFritzBox() *FritzBox {
 	return &FritzBox{
-		Devices:         [][]string{{"fritz.box", "", ""}},
-		Timeout:         10,
-		GetDeviceInfo:   true,
-		GetWLANInfo:     true,
-		GetWANInfo:      true,
-		GetDSLInfo:      true,
-		GetPPPInfo:      true,
-		GetMeshInfo:     []string{},
-		GetMeshClients:  false,
-		MeshClientTypes: []string{"WLAN"},
-		FullQueryCycle:  6,
+		Devices:                    [][]string{{"fritz.box", "", ""}},
+		Timeout:                    10,
+		GetDeviceInfo:              true,
+		GetFirmwareInfo:            false,
+		GetDeviceLog:               false,
+		DeviceLogBacklog:           false,
+		GetTimeInfo:                false,
+		GetWLANInfo:                true,
+		GetWLANRadioInfo:           false,
+		GetWLANNeighbours:          false,
+		GetWLANState:               false,
+		GetLANInfo:                 false,
+		GetWANInfo:                 true,
+		GetOnlineMonitor:           false,
+		GetDOCSISInfo:              false,
+		GetDSLInfo:                 true,
+		GetDSLIntervalStatistics:   false,
+		GetDSLSpectrum:             false,
+		DSLSpectrumBands:           32,
+		GetPPPInfo:                 true,
+		GetAHAInfo:                 false,
+		GetAHAStats:                false,
+		GetMeshInfo:                []string{},
+		GetMeshClients:             false,
+		GetMeshClientEvents:        false,
+		GetMeshNodes:               false,
+		MeshClientTypes:            []string{"WLAN"},
+		MeshClientNameInclude:      []string{},
+		MeshClientNameExclude:      []string{},
+		MeshClientMACInclude:       []string{},
+		MeshClientMACExclude:       []string{},
+		MeshClientInterfaceInclude: []string{},
+		MeshClientInterfaceExclude: []string{},
+		MeshClientReportUnnamed:    false,
+		DiscoverMeshSlaves:         false,
+		MeshSlaveCredentials:       make(map[string][]string),
+		MeshLinkWindow:             10,
+		MeshLinkDegradedRatio:      0.5,
+		MeshLinkDegradedCycles:     3,
+		FullQueryCycle:             6,
 
-		deviceInfos: make(map[string]*deviceInfo)}
+		deviceInfos:       make(map[string]*deviceInfo),
+		discoveredDevices: make(map[string][][]string),
+		meshTopologies:    make(map[string]*meshTopology),
+		lookupHost:        net.LookupHost}
 }
 
 func (plugin *FritzBox) SampleConfig() string {
@@ -107,20 +246,78 @@ func (plugin *FritzBox) SampleConfig() string {
   # tls_skip_verify = false
   ## Process Device services (if found)
   # get_device_info = true
+  ## Process firmware version and update infos (if found)
+  # get_firmware_info = false
+  ## Report new device log entries (if found)
+  # get_device_log = false
+  ## Report the entries already contained in the device log on the first query, too (otherwise only the entries added afterwards are reported)
+  # device_log_backlog = false
+  ## Process time and NTP synchronization status (if found)
+  # get_time_info = false
   ## Process WLAN services (if found)
   # get_wlan_info = true
+  ## Process WLAN radio stats (requires get_wlan_info)
+  # get_wlan_radio_info = false
+  ## Process WLAN neighbour scan results (requires get_wlan_info)
+  # get_wlan_neighbours = false
+  ## Process WLAN on/off state and report WLAN enable/disable events (requires get_wlan_info)
+  # get_wlan_state = false
+  ## Process LAN Ethernet services (if found)
+  # get_lan_info = false
   ## Process WAN services (if found)
   # get_wan_info = true
+  ## Process the online monitor traffic samples per priority queue via the web interface (requires login credentials)
+  # get_online_monitor = false
+  ## Process the DOCSIS channel stats of cable devices via the web interface (requires login credentials)
+  # get_docsis_info = false
   ## Process DSL services (if found)
   # get_dsl_info = true
+  ## Process DSL statistics per interval (showtime, last showtime, current day, quarter hour; requires get_dsl_info)
+  # get_dsl_interval_statistics = false
+  ## Process DSL spectrum (bits and SNR per tone) via the web interface (requires get_dsl_info and login credentials)
+  # get_dsl_spectrum = false
+  ## The number of bands the DSL spectrum is reduced to
+  # dsl_spectrum_bands = 32
   ## Process PPP services (if found)
   # get_ppp_info = true
+  ## Process smart home devices via the AHA HTTP interface (if found; requires login credentials)
+  # get_aha_info = false
+  ## Process energy, temperature and humidity history of smart home devices (requires get_aha_info)
+  # get_aha_stats = false
   ## Process Mesh infos for selected hosts (must be one of the hosts defined in devices)
   # get_mesh_info = []
   ## Get all mesh clients from mesh infos  
   # get_mesh_clients = false
   ## The type of mesh clients to report (WLAN, LAN; empty list reports all)
   # mesh_client_types = ["WLAN"]
+  ## The mesh clients to report by name, MAC address or peer interface name (glob patterns or regular expressions enclosed in
+  ## slashes like "/^client[0-9]+$/"; empty include lists report all)
+  # mesh_client_name_include = []
+  # mesh_client_name_exclude = []
+  # mesh_client_mac_include = []
+  # mesh_client_mac_exclude = []
+  # mesh_client_interface_include = []
+  # mesh_client_interface_exclude = []
+  ## Report mesh clients without a valid name under their MAC address (instead of ignoring them)
+  # mesh_client_report_unnamed = false
+  ## Report mesh client connect, disconnect and roaming events
+  # get_mesh_client_events = false
+  ## Report the state of the mesh nodes (mesh master and repeaters) found in mesh infos
+  # get_mesh_nodes = false
+  ## Automatically query the mesh slaves (repeaters) found in the mesh infos of the mesh masters
+  # discover_mesh_slaves = false
+  ## The credentials to use for discovered mesh slaves (mesh device name or address mapped to login, password; default are the mesh master's ones)
+  # mesh_slave_credentials = { "repeater" = ["", ""] }
+  ## Write the mesh topology of the mesh masters as Graphviz DOT graph to the given file (updated every full query cycle)
+  # mesh_topology_dot_file = ""
+  ## Write the mesh topology of the mesh masters as JSON to the given file (updated every full query cycle)
+  # mesh_topology_json_file = ""
+  ## The number of full query cycles used to derive the min, avg and median data rates of the mesh links
+  # mesh_link_window = 10
+  ## Flag a mesh link as degraded, if its current data rate falls below this fraction of its median data rate ...
+  # mesh_link_degraded_ratio = 0.5
+  ## ... for this number of consecutive full query cycles
+  # mesh_link_degraded_cycles = 3
   ## The cycle count, at which low-traffic stats are queried
   # full_query_cycle = 6
   ## Enable debug output
@@ -132,6 +329,19 @@ func (plugin *FritzBox) Description() string {
 	return "Gather FritzBox stats"
 }
 
+func (plugin *FritzBox) Start(a telegraf.Accumulator) error {
+	return nil
+}
+
+func (plugin *FritzBox) Stop() {
+	for _, deviceInfo := range plugin.deviceInfos {
+		err := plugin.webSessionLogout(deviceInfo)
+		if err != nil {
+			plugin.Log.Warnf("Logout from %s failed: %v", deviceInfo.BaseUrl.Hostname(), err)
+		}
+	}
+}
+
 func (plugin *FritzBox) Gather(a telegraf.Accumulator) error {
 	if len(plugin.Devices) == 0 {
 		return errors.New("fritzbox: Empty device list")
@@ -140,15 +350,13 @@ func (plugin *FritzBox) Gather(a telegraf.Accumulator) error {
 		if len(device) != 3 {
 			return fmt.Errorf("fritzbox: Invalid device entry: %s", device)
 		}
-		rawBaseUrl := device[0]
-		login := device[1]
-		password := device[2]
-		deviceInfo, err := plugin.fetchDeviceInfo(rawBaseUrl, login, password)
-		if err == nil {
-			a.AddError(plugin.processRootDevice(a, deviceInfo))
-		} else {
-			a.AddError(err)
-		}
+		plugin.gatherDevice(a, device[0], device[1], device[2])
+	}
+	for _, device := range plugin.getDiscoveredDevices() {
+		plugin.gatherDevice(a, device[0], device[1], device[2])
+	}
+	if plugin.queryCounter == 0 {
+		a.AddError(plugin.writeMeshTopologyFiles())
 	}
 	plugin.queryCounter++
 	if 1 < plugin.FullQueryCycle {
@@ -159,12 +367,118 @@ func (plugin *FritzBox) Gather(a telegraf.Accumulator) error {
 	return nil
 }
 
+func (plugin *FritzBox) gatherDevice(a telegraf.Accumulator, rawBaseUrl string, login string, password string) {
+	deviceInfo, err := plugin.fetchDeviceInfo(rawBaseUrl, login, password)
+	if err == nil {
+		if plugin.queryCounter == 0 {
+			// Re-sync the device's clock during full queries
+			deviceInfo.deviceClock = nil
+		}
+		a.AddError(plugin.processRootDevice(a, deviceInfo))
+	} else {
+		a.AddError(err)
+	}
+}
+
+func (plugin *FritzBox) getDiscoveredDevices() [][]string {
+	meshMasters := make([]string, 0, len(plugin.discoveredDevices))
+	for meshMaster := range plugin.discoveredDevices {
+		meshMasters = append(meshMasters, meshMaster)
+	}
+	sort.Strings(meshMasters)
+	devices := make([][]string, 0)
+	for _, meshMaster := range meshMasters {
+		devices = append(devices, plugin.discoveredDevices[meshMaster]...)
+	}
+	return devices
+}
+
+// WriteMeshTopology queries the mesh topology of all configured mesh masters and writes it in the given format (dot or json).
+func (plugin *FritzBox) WriteMeshTopology(out io.Writer, format string) error {
+	topologies := make([]*meshTopology, 0)
+	for _, device := range plugin.Devices {
+		if len(device) != 3 {
+			return fmt.Errorf("fritzbox: Invalid device entry: %s", device)
+		}
+		deviceInfo, err := plugin.fetchDeviceInfo(device[0], device[1], device[2])
+		if err != nil {
+			return err
+		}
+		if !deviceInfo.GetMeshInfo {
+			continue
+		}
+		service := deviceInfo.ServiceInfo.findService("urn:dslforum-org:service:Hosts:")
+		if service == nil {
+			return fmt.Errorf("fritzbox: No hosts service found for mesh master: %s", deviceInfo.BaseUrl.Hostname())
+		}
+		meshList, err := plugin.fetchMeshList(deviceInfo, service)
+		if err != nil {
+			return err
+		}
+		clientFilter, err := plugin.getMeshClientFilter()
+		if err != nil {
+			return err
+		}
+		topologies = append(topologies, newMeshTopology(deviceInfo.BaseUrl.Hostname(), meshList, clientFilter))
+	}
+	return writeMeshTopologies(out, topologies, format)
+}
+
+func (plugin *FritzBox) writeMeshTopologyFiles() error {
+	if len(plugin.meshTopologies) == 0 {
+		return nil
+	}
+	devices := make([]string, 0, len(plugin.meshTopologies))
+	for device := range plugin.meshTopologies {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	topologies := make([]*meshTopology, 0, len(devices))
+	for _, device := range devices {
+		topologies = append(topologies, plugin.meshTopologies[device])
+	}
+	if plugin.MeshTopologyDOTFile != "" {
+		err := writeMeshTopologyFile(plugin.MeshTopologyDOTFile, topologies, "dot")
+		if err != nil {
+			return err
+		}
+	}
+	if plugin.MeshTopologyJSONFile != "" {
+		err := writeMeshTopologyFile(plugin.MeshTopologyJSONFile, topologies, "json")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeMeshTopologyFile(path string, topologies []*meshTopology, format string) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	err = writeMeshTopologies(tempFile, topologies, format)
+	closeErr := tempFile.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	return os.Rename(tempFile.Name(), path)
+}
+
 func (plugin *FritzBox) processRootDevice(a telegraf.Accumulator, deviceInfo *deviceInfo) error {
 	if plugin.Debug {
 		plugin.Log.Infof("Considering root device: %s", deviceInfo.ServiceInfo.FriendlyName)
 	}
 	plugin.processServices(a, deviceInfo, deviceInfo.ServiceInfo.Services)
 	plugin.processDevices(a, deviceInfo, deviceInfo.ServiceInfo.Devices)
+	// The AHA HTTP interface is not bound to any TR-064 service
+	if plugin.GetAHAInfo && plugin.queryCounter == 0 {
+		a.AddError(plugin.processAHAInfo(a, deviceInfo))
+	}
 	return nil
 }
 
@@ -189,18 +503,43 @@ func (plugin *FritzBox) processServices(a telegraf.Accumulator, deviceInfo *devi
 			if plugin.GetDeviceInfo && fullQuery {
 				a.AddError(plugin.processDeviceInfoService(a, deviceInfo, &service))
 			}
+			if plugin.GetDeviceLog && fullQuery {
+				a.AddError(plugin.processDeviceLog(a, deviceInfo, &service))
+			}
+		} else if strings.HasPrefix(service.ServiceType, "urn:dslforum-org:service:Time:") {
+			if plugin.GetTimeInfo && fullQuery {
+				a.AddError(plugin.processTimeService(a, deviceInfo, &service))
+			}
+		} else if strings.HasPrefix(service.ServiceType, "urn:dslforum-org:service:UserInterface:") {
+			if plugin.GetFirmwareInfo && fullQuery {
+				a.AddError(plugin.processUserInterfaceService(a, deviceInfo, &service))
+			}
 		} else if strings.HasPrefix(service.ServiceType, "urn:dslforum-org:service:WLANConfiguration:") {
 			if plugin.GetWLANInfo && fullQuery {
 				a.AddError(plugin.processWLANConfigurationService(a, deviceInfo, &service))
 			}
+		} else if strings.HasPrefix(service.ServiceType, "urn:dslforum-org:service:LANEthernetInterfaceConfig:") {
+			if plugin.GetLANInfo {
+				a.AddError(plugin.processLANEthernetInterfaceConfigService(a, deviceInfo, &service, fullQuery))
+			}
 		} else if strings.HasPrefix(service.ServiceType, "urn:dslforum-org:service:WANCommonInterfaceConfig:") {
 			if plugin.GetWANInfo {
-				a.AddError(plugin.processWANCommonInterfaceConfigService(a, deviceInfo, &service))
+				a.AddError(plugin.processWANCommonInterfaceConfigService(a, deviceInfo, &service, fullQuery))
+			}
+			if plugin.GetOnlineMonitor {
+				a.AddError(plugin.processOnlineMonitor(a, deviceInfo, &service))
+			}
+			if plugin.GetDOCSISInfo && fullQuery {
+				a.AddError(plugin.processDOCSISInfo(a, deviceInfo, &service))
 			}
 		} else if strings.HasPrefix(service.ServiceType, "urn:dslforum-org:service:WANDSLInterfaceConfig:") {
 			if plugin.GetDSLInfo && fullQuery {
 				a.AddError(plugin.processDSLInterfaceConfigService(a, deviceInfo, &service))
 			}
+		} else if strings.HasPrefix(service.ServiceType, "urn:dslforum-org:service:WANDSLLinkConfig:") {
+			if plugin.GetDSLInfo && fullQuery {
+				a.AddError(plugin.processDSLLinkConfigService(a, deviceInfo, &service))
+			}
 		} else if strings.HasPrefix(service.ServiceType, "urn:dslforum-org:service:WANPPPConnection:") {
 			if plugin.GetPPPInfo && fullQuery {
 				a.AddError(plugin.processPPPConnectionService(a, deviceInfo, &service))
@@ -216,10 +555,7 @@ func (plugin *FritzBox) processServices(a telegraf.Accumulator, deviceInfo *devi
 }
 
 func (plugin *FritzBox) processDeviceInfoService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
-	info := struct {
-		UpTime    uint   `xml:"Body>GetInfoResponse>NewUpTime"`
-		ModelName string `xml:"Body>GetInfoResponse>NewModelName"`
-	}{}
+	var info deviceInfoServiceInfo
 	err := plugin.invokeDeviceService(deviceInfo, service, "GetInfo", &info)
 	if err != nil {
 		return err
@@ -227,262 +563,1697 @@ func (plugin *FritzBox) processDeviceInfoService(a telegraf.Accumulator, deviceI
 	tags := make(map[string]string)
 	tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
 	tags["fritz_service"] = service.ShortServiceId()
+	addOptionalTag(tags, "fritz_device_software_version", info.SoftwareVersion)
+	addOptionalTag(tags, "fritz_device_hardware_version", info.HardwareVersion)
 	fields := make(map[string]interface{})
 	fields["uptime"] = info.UpTime
 	fields["model_name"] = info.ModelName
+	addOptionalStringField(fields, "software_version", info.SoftwareVersion)
+	addOptionalStringField(fields, "hardware_version", info.HardwareVersion)
+	addOptionalStringField(fields, "serial_number", info.SerialNumber)
+	addOptionalStringField(fields, "provisioning_code", info.ProvisioningCode)
+	addOptionalStringField(fields, "description", info.Description)
 	a.AddCounter("fritzbox_device", fields, tags)
+	deviceInfo.deviceServiceInfo = &info
+	plugin.processUptime(a, deviceInfo, service, "reboot", info.UpTime, tags)
 	return nil
 }
 
-func (plugin *FritzBox) processWLANConfigurationService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
-	info := struct {
-		Status  string `xml:"Body>GetInfoResponse>NewStatus"`
-		Channel string `xml:"Body>GetInfoResponse>NewChannel"`
-		SSID    string `xml:"Body>GetInfoResponse>NewSSID"`
-	}{}
-	err := plugin.invokeDeviceService(deviceInfo, service, "GetInfo", &info)
-	if err != nil {
-		return err
+func (plugin *FritzBox) processUptime(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService, event string, uptime uint, tags map[string]string) {
+	serviceId := service.ShortServiceId()
+	sample := &uptimeSample{timestamp: time.Now(), uptime: uptime}
+	previousSample := deviceInfo.uptimeSamples[serviceId]
+	deviceInfo.uptimeSamples[serviceId] = sample
+	if !sample.isRestart(previousSample) {
+		return
 	}
-	totalAssociations := struct {
-		TotalAssociations uint `xml:"Body>GetTotalAssociationsResponse>NewTotalAssociations"`
-	}{}
-	err = plugin.invokeDeviceService(deviceInfo, service, "GetTotalAssociations", &totalAssociations)
+	eventTags := make(map[string]string)
+	for key, value := range tags {
+		eventTags[key] = value
+	}
+	eventTags["fritz_event"] = event
+	eventFields := make(map[string]interface{})
+	eventFields["uptime"] = uptime
+	eventFields["previous_uptime"] = previousSample.uptime
+	eventFields["outage_duration"] = uint(sample.outage(previousSample).Seconds())
+	a.AddFields("fritzbox_event", eventFields, eventTags, sample.startTime())
+}
+
+func (plugin *FritzBox) processDeviceLog(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
+	entries, err := plugin.fetchDeviceLog(deviceInfo, service)
 	if err != nil {
 		return err
 	}
-	if info.Status == "Up" {
+	if deviceInfo.deviceLogState == nil {
+		deviceInfo.deviceLogState = &deviceLogState{}
+		// Only mark the current entries as seen, unless the backlog is requested
+		if !plugin.DeviceLogBacklog {
+			deviceInfo.deviceLogState.filter(entries)
+			return nil
+		}
+	}
+	for _, entry := range deviceInfo.deviceLogState.filter(entries) {
 		tags := make(map[string]string)
 		tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
 		tags["fritz_service"] = service.ShortServiceId()
-		tags["fritz_wlan_channel"] = deviceInfo.BaseUrl.Hostname() + ":" + info.SSID + ":" + info.Channel
-		tags["fritz_wlan_network"] = deviceInfo.BaseUrl.Hostname() + ":" + info.SSID + ":" + getNetworkFromChannel(info.Channel)
+		addOptionalTag(tags, "fritz_log_category", entry.category)
 		fields := make(map[string]interface{})
-		fields["total_associations"] = totalAssociations.TotalAssociations
-		a.AddCounter("fritzbox_wlan", fields, tags)
+		if entry.messageId != 0 {
+			fields["message_id"] = entry.messageId
+		}
+		fields["message"] = entry.message
+		a.AddFields("fritzbox_log", fields, tags, entry.timestamp)
 	}
 	return nil
 }
 
-func getNetworkFromChannel(channel string) string {
-	if strings.Contains("1 2 3 4 5 6 7 8 9 10 11 12 13 14", channel) {
-		return "2G"
+func (plugin *FritzBox) fetchDeviceLog(deviceInfo *deviceInfo, service *tr64DescDeviceService) ([]*deviceLogEntry, error) {
+	// Newer firmware versions provide the structured log (including category and message id)
+	deviceLogPath := struct {
+		DeviceLogPath string `xml:"Body>X_AVM-DE_GetDeviceLogPathResponse>NewDeviceLogPath"`
+	}{}
+	err := plugin.invokeDeviceService(deviceInfo, service, "X_AVM-DE_GetDeviceLogPath", &deviceLogPath)
+	if err != nil {
+		return nil, err
 	}
-	return "5G"
-}
+	if deviceLogPath.DeviceLogPath != "" {
+		var deviceLog deviceLog
 
-func (plugin *FritzBox) processWANCommonInterfaceConfigService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
-	commonLinkProperties := struct {
-		Layer1UpstreamMaxBitRate   uint   `xml:"Body>GetCommonLinkPropertiesResponse>NewLayer1UpstreamMaxBitRate"`
-		Layer1DownstreamMaxBitRate uint   `xml:"Body>GetCommonLinkPropertiesResponse>NewLayer1DownstreamMaxBitRate"`
-		PhysicalLinkStatus         string `xml:"Body>GetCommonLinkPropertiesResponse>NewPhysicalLinkStatus"`
-		UpstreamCurrentMaxSpeed    uint   `xml:"Body>GetCommonLinkPropertiesResponse>NewX_AVM-DE_UpstreamCurrentMaxSpeed"`
-		DownstreamCurrentMaxSpeed  uint   `xml:"Body>GetCommonLinkPropertiesResponse>NewX_AVM-DE_DownstreamCurrentMaxSpeed"`
+		_, err = plugin.fetchXML(deviceInfo.BaseUrl, deviceLogPath.DeviceLogPath, &deviceLog)
+		if err != nil {
+			return nil, err
+		}
+		return deviceLog.getEntries(plugin.getDeviceLocation(deviceInfo)), nil
+	}
+	deviceLogText := struct {
+		DeviceLog string `xml:"Body>GetDeviceLogResponse>NewDeviceLog"`
 	}{}
-	err := plugin.invokeDeviceService(deviceInfo, service, "GetCommonLinkProperties", &commonLinkProperties)
+	err = plugin.invokeDeviceService(deviceInfo, service, "GetDeviceLog", &deviceLogText)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	// Use public IGD service instead of the found one, because IGD supports uint8 counters
-	igdWANCommonInterfaceConfigService := tr64DescDeviceService{
-		ServiceType: "urn:schemas-upnp-org:service:WANCommonInterfaceConfig:1",
-		ServiceId:   "urn:schemas-upnp-org:service:WANCommonInterfaceConfig:1",
-		ControlURL:  "/igdupnp/control/WANCommonIFC1"}
-	addonInfos := struct {
-		ByteSendRate         uint   `xml:"Body>GetAddonInfosResponse>NewByteSendRate"`
-		ByteReceiveRate      uint   `xml:"Body>GetAddonInfosResponse>NewByteReceiveRate"`
-		TotalBytesSent64     uint64 `xml:"Body>GetAddonInfosResponse>NewX_AVM_DE_TotalBytesSent64"`
-		TotalBytesReceived64 uint64 `xml:"Body>GetAddonInfosResponse>NewX_AVM_DE_TotalBytesReceived64"`
-	}{}
-	err = plugin.invokeDeviceService(deviceInfo, &igdWANCommonInterfaceConfigService, "GetAddonInfos", &addonInfos)
+	return parseDeviceLogText(deviceLogText.DeviceLog, plugin.getDeviceLocation(deviceInfo)), nil
+}
+
+// getDeviceLocation determines the time zone to use for interpreting the device's local
+// timestamps. The device's current UTC offset is taken from the Time service (see getDeviceTime).
+// If the latter is not available, the host's time zone is assumed.
+func (plugin *FritzBox) getDeviceLocation(deviceInfo *deviceInfo) *time.Location {
+	deviceTime, err := plugin.getDeviceTime(deviceInfo)
+	if err != nil {
+		if plugin.Debug {
+			plugin.Log.Infof("Failed to determine time zone of %s (cause: %v); using local time zone", deviceInfo.BaseUrl.Hostname(), err)
+		}
+		return time.Local
+	}
+	_, offset := deviceTime.Zone()
+	return time.FixedZone("", offset)
+}
+
+// getDeviceTime gets the device's current time (including its UTC offset). The device's clock is
+// queried via the Time service once per full query only. In between, the device's time is derived
+// from the local clock.
+func (plugin *FritzBox) getDeviceTime(deviceInfo *deviceInfo) (time.Time, error) {
+	if deviceInfo.deviceClock == nil {
+		deviceTime, err := plugin.fetchDeviceTime(deviceInfo)
+		deviceInfo.deviceClock = &deviceClock{
+			offset:   time.Until(deviceTime),
+			location: deviceTime.Location(),
+			err:      err,
+		}
+	}
+	if deviceInfo.deviceClock.err != nil {
+		return time.Time{}, deviceInfo.deviceClock.err
+	}
+	return time.Now().Add(deviceInfo.deviceClock.offset).In(deviceInfo.deviceClock.location), nil
+}
+
+// fetchDeviceTime fetches the device's current time (including its UTC offset) via the
+// Time service.
+func (plugin *FritzBox) fetchDeviceTime(deviceInfo *deviceInfo) (time.Time, error) {
+	timeService := deviceInfo.ServiceInfo.findService("urn:dslforum-org:service:Time:")
+	if timeService == nil {
+		return time.Time{}, fmt.Errorf("fritzbox: No Time service found for device: %s", deviceInfo.BaseUrl.Hostname())
+	}
+	var info timeServiceInfo
+	err := plugin.invokeDeviceService(deviceInfo, timeService, "GetInfo", &info)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, info.CurrentLocalTime)
+}
+
+type deviceInfoServiceInfo struct {
+	UpTime           uint   `xml:"Body>GetInfoResponse>NewUpTime"`
+	ModelName        string `xml:"Body>GetInfoResponse>NewModelName"`
+	SoftwareVersion  string `xml:"Body>GetInfoResponse>NewSoftwareVersion"`
+	HardwareVersion  string `xml:"Body>GetInfoResponse>NewHardwareVersion"`
+	SerialNumber     string `xml:"Body>GetInfoResponse>NewSerialNumber"`
+	ProvisioningCode string `xml:"Body>GetInfoResponse>NewProvisioningCode"`
+	Description      string `xml:"Body>GetInfoResponse>NewDescription"`
+}
+
+type timeServiceInfo struct {
+	NTPServer1          string `xml:"Body>GetInfoResponse>NewNTPServer1"`
+	NTPServer2          string `xml:"Body>GetInfoResponse>NewNTPServer2"`
+	CurrentLocalTime    string `xml:"Body>GetInfoResponse>NewCurrentLocalTime"`
+	LocalTimeZone       string `xml:"Body>GetInfoResponse>NewLocalTimeZone"`
+	LocalTimeZoneName   string `xml:"Body>GetInfoResponse>NewLocalTimeZoneName"`
+	DaylightSavingsUsed string `xml:"Body>GetInfoResponse>NewDaylightSavingsUsed"`
+	Status              string `xml:"Body>GetInfoResponse>NewStatus"`
+}
+
+func (plugin *FritzBox) processTimeService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
+	var info timeServiceInfo
+	requested := time.Now()
+	err := plugin.invokeDeviceService(deviceInfo, service, "GetInfo", &info)
 	if err != nil {
 		return err
 	}
-	//totalBytesSent := struct {
-	//	TotalBytesSent uint `xml:"Body>GetTotalBytesSentResponse>NewTotalBytesSent"`
-	//}{}
-	//err = plugin.invokeDeviceService(deviceInfo, service, "GetTotalBytesSent", &totalBytesSent)
-	//if err != nil {
-	//	return err
-	//}
-	//totalBytesReceived := struct {
-	//	TotalBytesReceived uint `xml:"Body>GetTotalBytesReceivedResponse>NewTotalBytesReceived"`
-	//}{}
-	//err = plugin.invokeDeviceService(deviceInfo, service, "GetTotalBytesReceived", &totalBytesReceived)
-	//if err != nil {
-	//	return err
-	//}
-	if commonLinkProperties.PhysicalLinkStatus == "Up" {
-		tags := make(map[string]string)
-		tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
-		tags["fritz_service"] = service.ShortServiceId()
-		fields := make(map[string]interface{})
-		fields["layer1_upstream_max_bit_rate"] = commonLinkProperties.Layer1UpstreamMaxBitRate
-		fields["layer1_downstream_max_bit_rate"] = commonLinkProperties.Layer1DownstreamMaxBitRate
-		fields["upstream_current_max_speed"] = commonLinkProperties.UpstreamCurrentMaxSpeed
-		fields["downstream_current_max_speed"] = commonLinkProperties.DownstreamCurrentMaxSpeed
-		//	fields["byte_send_rate"] = addonInfos.ByteSendRate
-		//	fields["byte_receive_rate"] = addonInfos.ByteReceiveRate
-		fields["total_bytes_sent"] = addonInfos.TotalBytesSent64
-		fields["total_bytes_received"] = addonInfos.TotalBytesReceived64
-		a.AddCounter("fritzbox_wan", fields, tags)
+	// Compare the device's time with the host's time in the middle of the request
+	received := time.Now()
+	hostTime := requested.Add(received.Sub(requested) / 2)
+	tags := make(map[string]string)
+	tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+	tags["fritz_service"] = service.ShortServiceId()
+	fields := make(map[string]interface{})
+	addOptionalStringField(fields, "ntp_server1", info.NTPServer1)
+	addOptionalStringField(fields, "ntp_server2", info.NTPServer2)
+	addOptionalStringField(fields, "local_time", info.CurrentLocalTime)
+	addOptionalStringField(fields, "local_time_zone", info.LocalTimeZone)
+	addOptionalStringField(fields, "local_time_zone_name", info.LocalTimeZoneName)
+	if info.DaylightSavingsUsed != "" {
+		fields["daylight_savings_used"] = info.DaylightSavingsUsed == "1"
+	}
+	deviceTime, err := parseTimeInfoLocalTime(info.CurrentLocalTime)
+	if err == nil {
+		fields["clock_offset"] = deviceTime.Sub(hostTime).Seconds()
+	} else if info.CurrentLocalTime != "" {
+		plugin.Log.Warnf("Ignoring invalid local time '%s' of %s: %v", info.CurrentLocalTime, deviceInfo.BaseUrl.Hostname(), err)
 	}
+	if info.Status != "" {
+		fields["status"] = info.Status
+		fields["synchronized"] = info.Status == "Synchronized"
+	}
+	a.AddCounter("fritzbox_time", fields, tags)
 	return nil
 }
 
-func (plugin *FritzBox) processDSLInterfaceConfigService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
+// parseTimeInfoLocalTime parses the device's local time as reported by the Time service. The
+// time is expected to include the device's UTC offset. Otherwise the host's time zone is assumed.
+func parseTimeInfoLocalTime(localTime string) (time.Time, error) {
+	parsed, err := time.Parse(time.RFC3339, localTime)
+	if err != nil {
+		parsed, err = time.ParseInLocation("2006-01-02T15:04:05", localTime, time.Local)
+	}
+	return parsed, err
+}
+
+func (plugin *FritzBox) processUserInterfaceService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
+	// Re-use the device info already queried by the DeviceInfo service (if enabled)
+	var currentInfo deviceInfoServiceInfo
+	if plugin.GetDeviceInfo && deviceInfo.deviceServiceInfo != nil {
+		currentInfo = *deviceInfo.deviceServiceInfo
+	} else {
+		deviceInfoService := deviceInfo.ServiceInfo.findService("urn:dslforum-org:service:DeviceInfo:")
+		if deviceInfoService != nil {
+			err := plugin.invokeDeviceService(deviceInfo, deviceInfoService, "GetInfo", &currentInfo)
+			if err != nil {
+				return err
+			}
+		}
+	}
 	info := struct {
-		Status                string `xml:"Body>GetInfoResponse>NewStatus"`
-		UpstreamCurrRate      uint   `xml:"Body>GetInfoResponse>NewUpstreamCurrRate"`
-		DownstreamCurrRate    uint   `xml:"Body>GetInfoResponse>NewDownstreamCurrRate"`
-		UpstreamMaxRate       uint   `xml:"Body>GetInfoResponse>NewUpstreamMaxRate"`
-		DownstreamMaxRate     uint   `xml:"Body>GetInfoResponse>NewDownstreamMaxRate"`
-		UpstreamNoiseMargin   uint   `xml:"Body>GetInfoResponse>NewUpstreamNoiseMargin"`
-		DownstreamNoiseMargin uint   `xml:"Body>GetInfoResponse>NewDownstreamNoiseMargin"`
-		UpstreamAttenuation   uint   `xml:"Body>GetInfoResponse>NewUpstreamAttenuation"`
-		DownstreamAttenuation uint   `xml:"Body>GetInfoResponse>NewDownstreamAttenuation"`
-		UpstreamPower         uint   `xml:"Body>GetInfoResponse>NewUpstreamPower"`
-		DownstreamPower       uint   `xml:"Body>GetInfoResponse>NewDownstreamPower"`
+		UpgradeAvailable string `xml:"Body>GetInfoResponse>NewUpgradeAvailable"`
+		Version          string `xml:"Body>GetInfoResponse>NewX_AVM-DE_Version"`
+		UpdateState      string `xml:"Body>GetInfoResponse>NewX_AVM-DE_UpdateState"`
+		BuildType        string `xml:"Body>GetInfoResponse>NewX_AVM-DE_BuildType"`
 	}{}
 	err := plugin.invokeDeviceService(deviceInfo, service, "GetInfo", &info)
 	if err != nil {
 		return err
 	}
-	statisticsTotal := struct {
-		ReceiveBlocks       uint `xml:"Body>GetStatisticsTotalResponse>NewReceiveBlocks"`
-		TransmitBlocks      uint `xml:"Body>GetStatisticsTotalResponse>NewTransmitBlocks"`
-		CellDelin           uint `xml:"Body>GetStatisticsTotalResponse>NewCellDelin"`
-		LinkRetrain         uint `xml:"Body>GetStatisticsTotalResponse>NewLinkRetrain"`
-		InitErrors          uint `xml:"Body>GetStatisticsTotalResponse>NewInitErrors"`
-		InitTimeouts        uint `xml:"Body>GetStatisticsTotalResponse>NewInitTimeouts"`
-		LossOfFraming       uint `xml:"Body>GetStatisticsTotalResponse>NewLossOfFraming"`
-		ErroredSecs         uint `xml:"Body>GetStatisticsTotalResponse>NewErroredSecs"`
-		SeverelyErroredSecs uint `xml:"Body>GetStatisticsTotalResponse>NewSeverelyErroredSecs"`
-		FECErrors           uint `xml:"Body>GetStatisticsTotalResponse>NewFECErrors"`
-		ATUCFECErrors       uint `xml:"Body>GetStatisticsTotalResponse>NewATUCFECErrors"`
-		HECErrors           uint `xml:"Body>GetStatisticsTotalResponse>NewHECErrors"`
-		ATUCHECErrors       uint `xml:"Body>GetStatisticsTotalResponse>NewATUCHECErrors"`
-		CRCErrors           uint `xml:"Body>GetStatisticsTotalResponse>NewCRCErrors"`
-		ATUCCRCErrors       uint `xml:"Body>GetStatisticsTotalResponse>NewATUCCRCErrors"`
+	avmInfo := struct {
+		AutoUpdateMode      string `xml:"Body>X_AVM-DE_GetInfoResponse>NewX_AVM-DE_AutoUpdateMode"`
+		UpdateCheckInterval string `xml:"Body>X_AVM-DE_GetInfoResponse>NewX_AVM-DE_UpdateCheckInterval"`
 	}{}
-	err = plugin.invokeDeviceService(deviceInfo, service, "GetStatisticsTotal", &statisticsTotal)
+	err = plugin.invokeDeviceService(deviceInfo, service, "X_AVM-DE_GetInfo", &avmInfo)
 	if err != nil {
 		return err
 	}
-	if info.Status == "Up" {
-		tags := make(map[string]string)
-		tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
-		tags["fritz_service"] = service.ShortServiceId()
-		fields := make(map[string]interface{})
-		fields["upstream_curr_rate"] = info.UpstreamCurrRate
-		fields["downstream_curr_rate"] = info.DownstreamCurrRate
-		fields["upstream_max_rate"] = info.UpstreamMaxRate
-		fields["downstream_max_rate"] = info.DownstreamMaxRate
-		fields["upstream_noise_margin"] = info.UpstreamNoiseMargin
-		fields["downstream_noise_margin"] = info.DownstreamNoiseMargin
-		fields["upstream_attenuation"] = info.UpstreamAttenuation
-		fields["downstream_attenuation"] = info.DownstreamAttenuation
-		fields["upstream_power"] = info.UpstreamPower
-		fields["downstream_power"] = info.DownstreamPower
-		fields["receive_blocks"] = statisticsTotal.ReceiveBlocks
-		fields["transmit_blocks"] = statisticsTotal.TransmitBlocks
-		fields["cell_delin"] = statisticsTotal.CellDelin
-		fields["link_retrain"] = statisticsTotal.LinkRetrain
-		fields["init_errors"] = statisticsTotal.InitErrors
-		fields["init_timeouts"] = statisticsTotal.InitTimeouts
-		fields["loss_of_framing"] = statisticsTotal.LossOfFraming
-		fields["errored_secs"] = statisticsTotal.ErroredSecs
-		fields["severly_errored_secs"] = statisticsTotal.SeverelyErroredSecs
-		fields["fec_errors"] = statisticsTotal.FECErrors
-		fields["atuc_fec_errors"] = statisticsTotal.ATUCFECErrors
-		fields["hec_errors"] = statisticsTotal.HECErrors
-		fields["atuc_hec_errors"] = statisticsTotal.ATUCHECErrors
-		fields["crc_errors"] = statisticsTotal.CRCErrors
-		fields["atuc_crc_errors"] = statisticsTotal.ATUCCRCErrors
-		a.AddCounter("fritzbox_dsl", fields, tags)
+	tags := make(map[string]string)
+	tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+	tags["fritz_service"] = service.ShortServiceId()
+	addOptionalTag(tags, "fritz_device_model", currentInfo.ModelName)
+	addOptionalTag(tags, "fritz_firmware_version", currentInfo.SoftwareVersion)
+	fields := make(map[string]interface{})
+	addOptionalStringField(fields, "current_version", currentInfo.SoftwareVersion)
+	updateAvailable := info.UpgradeAvailable == "1" && info.Version != ""
+	fields["update_available"] = updateAvailable
+	if updateAvailable {
+		fields["available_version"] = info.Version
 	}
+	addOptionalStringField(fields, "update_state", info.UpdateState)
+	addOptionalStringField(fields, "build_type", info.BuildType)
+	addOptionalStringField(fields, "auto_update_mode", avmInfo.AutoUpdateMode)
+	addOptionalIntField(fields, "update_check_interval", avmInfo.UpdateCheckInterval)
+	a.AddCounter("fritzbox_firmware", fields, tags)
 	return nil
 }
 
-func (plugin *FritzBox) processPPPConnectionService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
+func (plugin *FritzBox) processWLANConfigurationService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
 	info := struct {
-		ConnectionStatus     string `xml:"Body>GetInfoResponse>NewConnectionStatus"`
-		Uptime               uint   `xml:"Body>GetInfoResponse>NewUptime"`
-		UpstreamMaxBitRate   uint   `xml:"Body>GetInfoResponse>NewUpstreamMaxBitRate"`
-		DownstreamMaxBitRate uint   `xml:"Body>GetInfoResponse>NewDownstreamMaxBitRate"`
+		Enable        string `xml:"Body>GetInfoResponse>NewEnable"`
+		Status        string `xml:"Body>GetInfoResponse>NewStatus"`
+		Channel       string `xml:"Body>GetInfoResponse>NewChannel"`
+		SSID          string `xml:"Body>GetInfoResponse>NewSSID"`
+		Standard      string `xml:"Body>GetInfoResponse>NewStandard"`
+		FrequencyBand string `xml:"Body>GetInfoResponse>NewX_AVM-DE_FrequencyBand"`
 	}{}
 	err := plugin.invokeDeviceService(deviceInfo, service, "GetInfo", &info)
 	if err != nil {
 		return err
 	}
-	if info.ConnectionStatus == "Connected" {
-		tags := make(map[string]string)
-		tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+	totalAssociations := struct {
+		TotalAssociations uint `xml:"Body>GetTotalAssociationsResponse>NewTotalAssociations"`
+	}{}
+	err = plugin.invokeDeviceService(deviceInfo, service, "GetTotalAssociations", &totalAssociations)
+	if err != nil {
+		return err
+	}
+	extInfo := struct {
+		APType        string `xml:"Body>X_AVM-DE_GetWLANExtInfoResponse>NewX_AVM-DE_APType"`
+		FrequencyBand string `xml:"Body>X_AVM-DE_GetWLANExtInfoResponse>NewX_AVM-DE_FrequencyBand"`
+		ChannelWidth  string `xml:"Body>X_AVM-DE_GetWLANExtInfoResponse>NewX_AVM-DE_ChannelWidth"`
+		TransmitPower string `xml:"Body>X_AVM-DE_GetWLANExtInfoResponse>NewX_AVM-DE_TransmitPower"`
+	}{}
+	err = plugin.invokeDeviceService(deviceInfo, service, "X_AVM-DE_GetWLANExtInfo", &extInfo)
+	if err != nil {
+		return err
+	}
+	channelInfo := struct {
+		AutoChannelEnabled string `xml:"Body>GetChannelInfoResponse>NewX_AVM-DE_AutoChannelEnabled"`
+	}{}
+	err = plugin.invokeDeviceService(deviceInfo, service, "GetChannelInfo", &channelInfo)
+	if err != nil {
+		return err
+	}
+	band := getBandFromFrequency(extInfo.FrequencyBand)
+	if band == "" {
+		band = getBandFromFrequency(info.FrequencyBand)
+	}
+	if band == "" {
+		band = getBandFromChannel(info.Channel)
+	}
+	if band == "" {
+		band = getNetworkFromChannel(info.Channel)
+	}
+	if plugin.GetWLANState {
+		enabled := info.Status == "Up"
+		if info.Enable != "" {
+			enabled = info.Enable == "1"
+		}
+		plugin.processWLANState(a, deviceInfo, service, enabled, info.SSID, band, extInfo.APType == "guest")
+	}
+	if info.Status == "Up" {
+		tags := make(map[string]string)
+		tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+		tags["fritz_service"] = service.ShortServiceId()
+		tags["fritz_wlan_channel"] = deviceInfo.BaseUrl.Hostname() + ":" + info.SSID + ":" + info.Channel
+		tags["fritz_wlan_network"] = deviceInfo.BaseUrl.Hostname() + ":" + info.SSID + ":" + band
+		tags["fritz_wlan_band"] = band
+		tags["fritz_wlan_guest"] = strconv.FormatBool(extInfo.APType == "guest")
+		if info.Standard != "" {
+			tags["fritz_wlan_standard"] = info.Standard
+		}
+		if extInfo.ChannelWidth != "" {
+			tags["fritz_wlan_bandwidth"] = extInfo.ChannelWidth
+		}
+		if channelInfo.AutoChannelEnabled != "" {
+			tags["fritz_wlan_autochannel"] = strconv.FormatBool(channelInfo.AutoChannelEnabled == "1")
+		}
+		fields := make(map[string]interface{})
+		fields["total_associations"] = totalAssociations.TotalAssociations
+		a.AddCounter("fritzbox_wlan", fields, tags)
+		var neighbourList *wlanNeighbourList
+		if plugin.GetWLANNeighbours {
+			neighbourList, err = plugin.fetchWLANNeighbourList(deviceInfo, service)
+			// Report the error, but continue with the radio stats (without the neighbour counts)
+			a.AddError(err)
+		}
+		if neighbourList != nil {
+			for _, neighbour := range neighbourList.Items {
+				neighbourTags := make(map[string]string)
+				neighbourTags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+				neighbourTags["fritz_service"] = service.ShortServiceId()
+				neighbourTags["fritz_wlan_network"] = tags["fritz_wlan_network"]
+				neighbourTags["fritz_wlan_neighbour_ssid"] = neighbour.SSID
+				neighbourTags["fritz_wlan_neighbour_bssid"] = neighbour.BSSID
+				neighbourTags["fritz_wlan_neighbour_band"] = getBandFromChannel(neighbour.Channel)
+				neighbourFields := make(map[string]interface{})
+				neighbourFields["channel"], _ = strconv.Atoi(neighbour.Channel)
+				neighbourFields["rssi"] = neighbour.RSSI
+				a.AddCounter("fritzbox_wlan_neighbour", neighbourFields, neighbourTags)
+			}
+		}
+		if plugin.GetWLANRadioInfo {
+			statistics := struct {
+				TotalPacketsSent     uint64 `xml:"Body>GetStatisticsResponse>NewTotalPacketsSent"`
+				TotalPacketsReceived uint64 `xml:"Body>GetStatisticsResponse>NewTotalPacketsReceived"`
+			}{}
+			err = plugin.invokeDeviceService(deviceInfo, service, "GetStatistics", &statistics)
+			if err != nil {
+				return err
+			}
+			packetStatistics := struct {
+				TotalPacketsSent     uint64 `xml:"Body>GetPacketStatisticsResponse>NewTotalPacketsSent"`
+				TotalPacketsReceived uint64 `xml:"Body>GetPacketStatisticsResponse>NewTotalPacketsReceived"`
+			}{}
+			err = plugin.invokeDeviceService(deviceInfo, service, "GetPacketStatistics", &packetStatistics)
+			if err != nil {
+				return err
+			}
+			if packetStatistics.TotalPacketsSent != 0 || packetStatistics.TotalPacketsReceived != 0 {
+				statistics.TotalPacketsSent = packetStatistics.TotalPacketsSent
+				statistics.TotalPacketsReceived = packetStatistics.TotalPacketsReceived
+			}
+			radioFields := make(map[string]interface{})
+			radioFields["packets_sent"] = statistics.TotalPacketsSent
+			radioFields["packets_received"] = statistics.TotalPacketsReceived
+			radioFields["channel"], _ = strconv.Atoi(info.Channel)
+			if extInfo.ChannelWidth != "" {
+				radioFields["channel_width"], _ = strconv.Atoi(extInfo.ChannelWidth)
+			}
+			if extInfo.TransmitPower != "" {
+				radioFields["transmit_power"], _ = strconv.Atoi(extInfo.TransmitPower)
+			}
+			if channelInfo.AutoChannelEnabled != "" {
+				radioFields["autochannel"] = channelInfo.AutoChannelEnabled == "1"
+			}
+			if neighbourList != nil {
+				radioFields["neighbours"] = len(neighbourList.Items)
+				radioFields["neighbours_on_channel"] = neighbourList.countChannel(info.Channel)
+			}
+			a.AddCounter("fritzbox_wlan_radio", radioFields, tags)
+		}
+	}
+	return nil
+}
+
+func (plugin *FritzBox) processWLANState(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService, enabled bool, ssid string, band string, guest bool) {
+	nightControl := struct {
+		NightControl string `xml:"Body>X_AVM-DE_GetNightControlResponse>NewNightControl"`
+	}{}
+	a.AddError(plugin.invokeDeviceService(deviceInfo, service, "X_AVM-DE_GetNightControl", &nightControl))
+	wlanTimer := struct {
+		TimerActive string `xml:"Body>X_AVM-DE_GetWLANTimerResponse>NewX_AVM-DE_TimerActive"`
+	}{}
+	a.AddError(plugin.invokeDeviceService(deviceInfo, service, "X_AVM-DE_GetWLANTimer", &wlanTimer))
+	now := time.Now()
+	serviceId := service.ShortServiceId()
+	wlanState := &wlanState{enabled: enabled, since: now}
+	previousWLANState := deviceInfo.wlanStates[serviceId]
+	if previousWLANState != nil && previousWLANState.enabled == enabled {
+		wlanState.since = previousWLANState.since
+	}
+	deviceInfo.wlanStates[serviceId] = wlanState
+	tags := make(map[string]string)
+	tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+	tags["fritz_service"] = serviceId
+	tags["fritz_wlan_network"] = deviceInfo.BaseUrl.Hostname() + ":" + ssid + ":" + band
+	tags["fritz_wlan_guest"] = strconv.FormatBool(guest)
+	if previousWLANState != nil && previousWLANState.enabled != enabled {
+		eventTags := make(map[string]string)
+		for key, value := range tags {
+			eventTags[key] = value
+		}
+		eventTags["fritz_wlan_event"] = "disable"
+		if enabled {
+			eventTags["fritz_wlan_event"] = "enable"
+		}
+		eventFields := make(map[string]interface{})
+		eventFields["dwell_time"] = uint(now.Sub(previousWLANState.since).Seconds())
+		a.AddFields("fritzbox_wlan_event", eventFields, eventTags)
+	}
+	fields := make(map[string]interface{})
+	fields["enabled"] = enabled
+	fields["night_control"] = nightControl.NightControl == "1"
+	fields["timer_active"] = wlanTimer.TimerActive == "1"
+	fields["enabled_duration"] = uint(0)
+	if enabled {
+		fields["enabled_duration"] = uint(now.Sub(wlanState.since).Seconds())
+	}
+	a.AddCounter("fritzbox_wlan_state", fields, tags)
+}
+
+func (plugin *FritzBox) fetchWLANNeighbourList(deviceInfo *deviceInfo, service *tr64DescDeviceService) (*wlanNeighbourList, error) {
+	neighbourListPath := struct {
+		NeighbourListPath string `xml:"Body>X_AVM-DE_GetNeighbourWLANListResponse>NewX_AVM-DE_NeighbourWLANListPath"`
+	}{}
+	err := plugin.invokeDeviceService(deviceInfo, service, "X_AVM-DE_GetNeighbourWLANList", &neighbourListPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var neighbourList wlanNeighbourList
+
+	if neighbourListPath.NeighbourListPath != "" {
+		_, err = plugin.fetchXML(deviceInfo.BaseUrl, neighbourListPath.NeighbourListPath, &neighbourList)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &neighbourList, nil
+}
+
+func getBandFromFrequency(frequencyBand string) string {
+	switch frequencyBand {
+	case "2400":
+		return "2G"
+	case "5000":
+		return "5G"
+	case "6000":
+		return "6G"
+	}
+	return ""
+}
+
+func getBandFromChannel(channel string) string {
+	channelNumber, err := strconv.Atoi(channel)
+	if err != nil {
+		return ""
+	}
+	if 1 <= channelNumber && channelNumber <= 14 {
+		return "2G"
+	}
+	if 32 <= channelNumber && channelNumber <= 177 {
+		return "5G"
+	}
+	if 181 <= channelNumber && channelNumber <= 233 {
+		return "6G"
+	}
+	return ""
+}
+
+// getNetworkFromChannel derives the band the way previous versions did (for channels outside the known
+// bands). This keeps the fritz_wlan_network tag non-empty and stable for existing setups.
+func getNetworkFromChannel(channel string) string {
+	if strings.Contains("1 2 3 4 5 6 7 8 9 10 11 12 13 14", channel) {
+		return "2G"
+	}
+	return "5G"
+}
+
+func (plugin *FritzBox) processLANEthernetInterfaceConfigService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService, fullQuery bool) error {
+	info := struct {
+		Enable     string `xml:"Body>GetInfoResponse>NewEnable"`
+		Status     string `xml:"Body>GetInfoResponse>NewStatus"`
+		MACAddress string `xml:"Body>GetInfoResponse>NewMACAddress"`
+		MaxBitRate string `xml:"Body>GetInfoResponse>NewMaxBitRate"`
+		DuplexMode string `xml:"Body>GetInfoResponse>NewDuplexMode"`
+	}{}
+	err := plugin.invokeDeviceService(deviceInfo, service, "GetInfo", &info)
+	if err != nil {
+		return err
+	}
+	statistics := struct {
+		BytesSent       uint64 `xml:"Body>GetStatisticsResponse>NewBytesSent"`
+		BytesReceived   uint64 `xml:"Body>GetStatisticsResponse>NewBytesReceived"`
+		PacketsSent     uint64 `xml:"Body>GetStatisticsResponse>NewPacketsSent"`
+		PacketsReceived uint64 `xml:"Body>GetStatisticsResponse>NewPacketsReceived"`
+	}{}
+	err = plugin.invokeDeviceService(deviceInfo, service, "GetStatistics", &statistics)
+	if err != nil {
+		return err
+	}
+	tags := make(map[string]string)
+	tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+	tags["fritz_service"] = service.ShortServiceId()
+	fields := make(map[string]interface{})
+	fields["enabled"] = info.Enable == "1"
+	addOptionalStringField(fields, "status", info.Status)
+	addOptionalStringField(fields, "duplex_mode", info.DuplexMode)
+	addOptionalIntField(fields, "configured_max_bit_rate", info.MaxBitRate)
+	fields["bytes_sent"] = statistics.BytesSent
+	fields["bytes_received"] = statistics.BytesReceived
+	fields["packets_sent"] = statistics.PacketsSent
+	fields["packets_received"] = statistics.PacketsReceived
+	a.AddCounter("fritzbox_lan_port", fields, tags)
+	if fullQuery {
+		hostsService := deviceInfo.ServiceInfo.findService("urn:dslforum-org:service:Hosts:")
+		if hostsService != nil {
+			hostList, err := plugin.fetchHostList(deviceInfo, hostsService)
+			if err != nil {
+				return err
+			}
+			for _, port := range hostList.getEthernetPorts() {
+				portTags := make(map[string]string)
+				portTags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+				portTags["fritz_service"] = hostsService.ShortServiceId()
+				portTags["fritz_lan_port"] = "LAN:" + strconv.Itoa(port.port)
+				portFields := make(map[string]interface{})
+				if port.speed > 0 {
+					portFields["link_speed"] = port.speed
+				}
+				portFields["hosts"] = port.hosts
+				a.AddCounter("fritzbox_lan_link", portFields, portTags)
+			}
+		}
+	}
+	return nil
+}
+
+func (plugin *FritzBox) processWANCommonInterfaceConfigService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService, fullQuery bool) error {
+	commonLinkProperties := struct {
+		Layer1UpstreamMaxBitRate   uint   `xml:"Body>GetCommonLinkPropertiesResponse>NewLayer1UpstreamMaxBitRate"`
+		Layer1DownstreamMaxBitRate uint   `xml:"Body>GetCommonLinkPropertiesResponse>NewLayer1DownstreamMaxBitRate"`
+		PhysicalLinkStatus         string `xml:"Body>GetCommonLinkPropertiesResponse>NewPhysicalLinkStatus"`
+		UpstreamCurrentMaxSpeed    uint   `xml:"Body>GetCommonLinkPropertiesResponse>NewX_AVM-DE_UpstreamCurrentMaxSpeed"`
+		DownstreamCurrentMaxSpeed  uint   `xml:"Body>GetCommonLinkPropertiesResponse>NewX_AVM-DE_DownstreamCurrentMaxSpeed"`
+		WANAccessType              string `xml:"Body>GetCommonLinkPropertiesResponse>NewWANAccessType"`
+	}{}
+	err := plugin.invokeDeviceService(deviceInfo, service, "GetCommonLinkProperties", &commonLinkProperties)
+	if err != nil {
+		return err
+	}
+	deviceInfo.wanAccessType = normalizeWANAccessType(commonLinkProperties.WANAccessType)
+	connectionUptime, connected, err := plugin.getWANConnectionUptime(deviceInfo)
+	if err != nil {
+		return err
+	}
+	// Connection restarts are tracked on every query to not miss any of them
+	if connected {
+		uptime := &uptimeSample{timestamp: time.Now(), uptime: connectionUptime}
+		if uptime.isRestart(deviceInfo.connectionUptime) {
+			deviceInfo.connectionResets++
+		}
+		deviceInfo.connectionUptime = uptime
+	}
+	// The DSL line stats are reported by the DSL service itself (during full queries)
+	if deviceInfo.wanAccessType != "dsl" && fullQuery {
+		lineFields := make(map[string]interface{})
+		lineFields["link_up"] = commonLinkProperties.PhysicalLinkStatus == "Up"
+		if commonLinkProperties.PhysicalLinkStatus == "Up" {
+			lineFields["downstream_sync_rate"] = commonLinkProperties.Layer1DownstreamMaxBitRate
+			lineFields["upstream_sync_rate"] = commonLinkProperties.Layer1UpstreamMaxBitRate
+		}
+		if connected {
+			lineFields["connection_uptime"] = connectionUptime
+		}
+		lineFields["connection_resets"] = deviceInfo.connectionResets
+		plugin.addLineMetric(a, deviceInfo, service, lineFields)
+	}
+	// Use public IGD service instead of the found one, because IGD supports uint8 counters
+	igdWANCommonInterfaceConfigService := tr64DescDeviceService{
+		ServiceType: "urn:schemas-upnp-org:service:WANCommonInterfaceConfig:1",
+		ServiceId:   "urn:schemas-upnp-org:service:WANCommonInterfaceConfig:1",
+		ControlURL:  "/igdupnp/control/WANCommonIFC1"}
+	addonInfos := struct {
+		ByteSendRate         uint   `xml:"Body>GetAddonInfosResponse>NewByteSendRate"`
+		ByteReceiveRate      uint   `xml:"Body>GetAddonInfosResponse>NewByteReceiveRate"`
+		TotalBytesSent64     uint64 `xml:"Body>GetAddonInfosResponse>NewX_AVM_DE_TotalBytesSent64"`
+		TotalBytesReceived64 uint64 `xml:"Body>GetAddonInfosResponse>NewX_AVM_DE_TotalBytesReceived64"`
+	}{}
+	err = plugin.invokeDeviceService(deviceInfo, &igdWANCommonInterfaceConfigService, "GetAddonInfos", &addonInfos)
+	if err != nil {
+		return err
+	}
+	//totalBytesSent := struct {
+	//	TotalBytesSent uint `xml:"Body>GetTotalBytesSentResponse>NewTotalBytesSent"`
+	//}{}
+	//err = plugin.invokeDeviceService(deviceInfo, service, "GetTotalBytesSent", &totalBytesSent)
+	//if err != nil {
+	//	return err
+	//}
+	//totalBytesReceived := struct {
+	//	TotalBytesReceived uint `xml:"Body>GetTotalBytesReceivedResponse>NewTotalBytesReceived"`
+	//}{}
+	//err = plugin.invokeDeviceService(deviceInfo, service, "GetTotalBytesReceived", &totalBytesReceived)
+	//if err != nil {
+	//	return err
+	//}
+	if commonLinkProperties.PhysicalLinkStatus == "Up" {
+		tags := make(map[string]string)
+		tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+		tags["fritz_service"] = service.ShortServiceId()
+		plugin.addWANAccessTypeTag(tags, deviceInfo)
+		fields := make(map[string]interface{})
+		fields["layer1_upstream_max_bit_rate"] = commonLinkProperties.Layer1UpstreamMaxBitRate
+		fields["layer1_downstream_max_bit_rate"] = commonLinkProperties.Layer1DownstreamMaxBitRate
+		fields["upstream_current_max_speed"] = commonLinkProperties.UpstreamCurrentMaxSpeed
+		fields["downstream_current_max_speed"] = commonLinkProperties.DownstreamCurrentMaxSpeed
+		//	fields["byte_send_rate"] = addonInfos.ByteSendRate
+		//	fields["byte_receive_rate"] = addonInfos.ByteReceiveRate
+		fields["total_bytes_sent"] = addonInfos.TotalBytesSent64
+		fields["total_bytes_received"] = addonInfos.TotalBytesReceived64
+		counters := &wanCounterSample{
+			timestamp:     time.Now(),
+			bytesSent:     addonInfos.TotalBytesSent64,
+			bytesReceived: addonInfos.TotalBytesReceived64,
+			uptime:        connectionUptime,
+		}
+		tx, rx, valid := counters.throughput(deviceInfo.wanCounters)
+		if valid {
+			fields["tx_bits_per_second"] = tx
+			fields["rx_bits_per_second"] = rx
+			fields["upstream_utilization"] = linkUtilization(tx, commonLinkProperties.UpstreamCurrentMaxSpeed)
+			fields["downstream_utilization"] = linkUtilization(rx, commonLinkProperties.DownstreamCurrentMaxSpeed)
+		} else if deviceInfo.wanCounters != nil && plugin.Debug {
+			plugin.Log.Infof("WAN counters of %s reset; skipping throughput", deviceInfo.BaseUrl.Hostname())
+		}
+		deviceInfo.wanCounters = counters
+		a.AddCounter("fritzbox_wan", fields, tags)
+	}
+	return nil
+}
+
+// getWANConnectionUptime gets the uptime of the currently connected WAN connection (IP or PPP).
+func (plugin *FritzBox) getWANConnectionUptime(deviceInfo *deviceInfo) (uint, bool, error) {
+	for _, serviceType := range []string{"urn:dslforum-org:service:WANIPConnection:", "urn:dslforum-org:service:WANPPPConnection:"} {
+		service := deviceInfo.ServiceInfo.findService(serviceType)
+		if service == nil {
+			continue
+		}
+		info := struct {
+			ConnectionStatus string `xml:"Body>GetInfoResponse>NewConnectionStatus"`
+			Uptime           uint   `xml:"Body>GetInfoResponse>NewUptime"`
+		}{}
+		err := plugin.invokeDeviceService(deviceInfo, service, "GetInfo", &info)
+		if err != nil {
+			return 0, false, err
+		}
+		if info.ConnectionStatus == "Connected" {
+			return info.Uptime, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+func (plugin *FritzBox) addLineMetric(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService, fields map[string]interface{}) {
+	tags := make(map[string]string)
+	tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+	tags["fritz_service"] = service.ShortServiceId()
+	plugin.addWANAccessTypeTag(tags, deviceInfo)
+	a.AddCounter("fritzbox_line", fields, tags)
+}
+
+func (plugin *FritzBox) addWANAccessTypeTag(tags map[string]string, deviceInfo *deviceInfo) {
+	addOptionalTag(tags, "fritz_wan_access_type", plugin.getWANAccessType(deviceInfo))
+}
+
+func (plugin *FritzBox) getWANAccessType(deviceInfo *deviceInfo) string {
+	if deviceInfo.wanAccessType == "" {
+		service := deviceInfo.ServiceInfo.findService("urn:dslforum-org:service:WANCommonInterfaceConfig:")
+		if service != nil {
+			commonLinkProperties := struct {
+				WANAccessType string `xml:"Body>GetCommonLinkPropertiesResponse>NewWANAccessType"`
+			}{}
+			err := plugin.invokeDeviceService(deviceInfo, service, "GetCommonLinkProperties", &commonLinkProperties)
+			if err != nil {
+				plugin.Log.Warnf("Failed to query WAN access type of %s: %v", deviceInfo.BaseUrl.Hostname(), err)
+			}
+			deviceInfo.wanAccessType = normalizeWANAccessType(commonLinkProperties.WANAccessType)
+		}
+	}
+	return deviceInfo.wanAccessType
+}
+
+// normalizeWANAccessType maps the reported access types (DSL, Ethernet, X_AVM-DE_Fiber,
+// X_AVM-DE_Cable, X_AVM-DE_UMTS, X_AVM-DE_LTE, ...) to lower case names without vendor prefix.
+func normalizeWANAccessType(accessType string) string {
+	return strings.ToLower(strings.TrimPrefix(accessType, "X_AVM-DE_"))
+}
+
+func (plugin *FritzBox) processOnlineMonitor(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
+	var monitor onlineMonitor
+	err := plugin.fetchWebData(deviceInfo, "netMoni", &monitor)
+	if err != nil {
+		return err
+	}
+	// The device does not report the samples' timestamps. Initially, they are derived from the
+	// device's clock (if available) as the device's clock controls the sampling. Afterwards, they
+	// continue the timestamps of the previous query to keep them stable.
+	fetched := time.Now()
+	now, err := plugin.getDeviceTime(deviceInfo)
+	if err != nil {
+		if plugin.Debug {
+			plugin.Log.Infof("Failed to query time of %s (cause: %v); using local time", deviceInfo.BaseUrl.Hostname(), err)
+		}
+		now = fetched
+	}
+	for syncGroupIndex := range monitor.Data.SyncGroups {
+		syncGroup := &monitor.Data.SyncGroups[syncGroupIndex]
+		samplingInterval := syncGroup.getSamplingInterval()
+		latest := now
+		newSampleCount := syncGroup.getSampleCount()
+		// The sample buffer overlaps between queries; only report samples not reported yet
+		state := deviceInfo.onlineMonitorStates[syncGroup.Name]
+		if state != nil {
+			expected := int((fetched.Sub(state.fetched) + samplingInterval/2) / samplingInterval)
+			count, overlapping := syncGroup.getNewSampleCount(state.syncGroup, expected)
+			if overlapping {
+				latest = state.latest.Add(time.Duration(count) * samplingInterval)
+				newSampleCount = count
+			} else {
+				newSampleCount = min(newSampleCount, int(latest.Sub(state.latest)/samplingInterval))
+			}
+		}
+		for _, sample := range syncGroup.getSamples(latest, newSampleCount) {
+			tags := make(map[string]string)
+			tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+			tags["fritz_service"] = service.ShortServiceId()
+			plugin.addWANAccessTypeTag(tags, deviceInfo)
+			addOptionalTag(tags, "fritz_online_monitor_group", syncGroup.Name)
+			a.AddCounter("fritzbox_online_monitor", sample.fields, tags, sample.timestamp)
+		}
+		deviceInfo.onlineMonitorStates[syncGroup.Name] = &onlineMonitorState{syncGroup: syncGroup, latest: latest, fetched: fetched}
+	}
+	return nil
+}
+
+func (plugin *FritzBox) processDOCSISInfo(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
+	if plugin.getWANAccessType(deviceInfo) != "cable" {
+		return nil
+	}
+	var info docsisInfo
+	err := plugin.fetchWebData(deviceInfo, "docInfo", &info)
+	if err != nil {
+		return err
+	}
+	channelTables := []struct {
+		direction string
+		version   string
+		channels  []docsisChannel
+	}{
+		{"downstream", "3.0", info.Data.ChannelDs.Docsis30},
+		{"downstream", "3.1", info.Data.ChannelDs.Docsis31},
+		{"upstream", "3.0", info.Data.ChannelUs.Docsis30},
+		{"upstream", "3.1", info.Data.ChannelUs.Docsis31},
+	}
+	for _, channelTable := range channelTables {
+		for _, channel := range channelTable.channels {
+			tags := make(map[string]string)
+			tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+			tags["fritz_service"] = service.ShortServiceId()
+			plugin.addWANAccessTypeTag(tags, deviceInfo)
+			tags["fritz_docsis_direction"] = channelTable.direction
+			tags["fritz_docsis_version"] = channelTable.version
+			addOptionalTag(tags, "fritz_docsis_channel", string(channel.ChannelID))
+			a.AddCounter("fritzbox_docsis_channel", channel.fields(), tags)
+		}
+	}
+	return nil
+}
+
+func (plugin *FritzBox) processDSLInterfaceConfigService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
+	info := struct {
+		Status                string `xml:"Body>GetInfoResponse>NewStatus"`
+		UpstreamCurrRate      uint   `xml:"Body>GetInfoResponse>NewUpstreamCurrRate"`
+		DownstreamCurrRate    uint   `xml:"Body>GetInfoResponse>NewDownstreamCurrRate"`
+		UpstreamMaxRate       uint   `xml:"Body>GetInfoResponse>NewUpstreamMaxRate"`
+		DownstreamMaxRate     uint   `xml:"Body>GetInfoResponse>NewDownstreamMaxRate"`
+		UpstreamNoiseMargin   uint   `xml:"Body>GetInfoResponse>NewUpstreamNoiseMargin"`
+		DownstreamNoiseMargin uint   `xml:"Body>GetInfoResponse>NewDownstreamNoiseMargin"`
+		UpstreamAttenuation   uint   `xml:"Body>GetInfoResponse>NewUpstreamAttenuation"`
+		DownstreamAttenuation uint   `xml:"Body>GetInfoResponse>NewDownstreamAttenuation"`
+		UpstreamPower         uint   `xml:"Body>GetInfoResponse>NewUpstreamPower"`
+		DownstreamPower       uint   `xml:"Body>GetInfoResponse>NewDownstreamPower"`
+		StandardUsed          string `xml:"Body>GetInfoResponse>NewStandardUsed"`
+		LineEncoding          string `xml:"Body>GetInfoResponse>NewLineEncoding"`
+		DataPath              string `xml:"Body>GetInfoResponse>NewDataPath"`
+		InterleaveDepth       string `xml:"Body>GetInfoResponse>NewInterleaveDepth"`
+		ATURVendor            string `xml:"Body>GetInfoResponse>NewATURVendor"`
+		ATUCVendor            string `xml:"Body>GetInfoResponse>NewATUCVendor"`
+		UpstreamINP           string `xml:"Body>GetInfoResponse>NewX_AVM-DE_UpstreamINP"`
+		DownstreamINP         string `xml:"Body>GetInfoResponse>NewX_AVM-DE_DownstreamINP"`
+		UpstreamDelay         string `xml:"Body>GetInfoResponse>NewX_AVM-DE_UpstreamDelay"`
+		DownstreamDelay       string `xml:"Body>GetInfoResponse>NewX_AVM-DE_DownstreamDelay"`
+		ShowtimeStart         string `xml:"Body>GetInfoResponse>NewShowtimeStart"`
+	}{}
+	err := plugin.invokeDeviceService(deviceInfo, service, "GetInfo", &info)
+	if err != nil {
+		return err
+	}
+	statisticsTotal := struct {
+		Statistics dslStatistics `xml:"Body>GetStatisticsTotalResponse"`
+	}{}
+	err = plugin.invokeDeviceService(deviceInfo, service, "GetStatisticsTotal", &statisticsTotal)
+	if err != nil {
+		return err
+	}
+	// The line stats of all other access types are reported by the WAN service
+	if plugin.getWANAccessType(deviceInfo) == "dsl" {
+		lineFields := make(map[string]interface{})
+		lineFields["link_up"] = info.Status == "Up"
+		if info.Status == "Up" {
+			lineFields["downstream_sync_rate"] = info.DownstreamCurrRate * 1000
+			lineFields["upstream_sync_rate"] = info.UpstreamCurrRate * 1000
+			showtimeStart, err := strconv.ParseUint(info.ShowtimeStart, 10, 0)
+			if err == nil {
+				lineFields["line_uptime"] = uint(showtimeStart)
+			}
+		}
+		lineFields["resyncs"] = statisticsTotal.Statistics.LinkRetrain
+		lineFields["errored_secs"] = statisticsTotal.Statistics.ErroredSecs
+		lineFields["severely_errored_secs"] = statisticsTotal.Statistics.SeverelyErroredSecs
+		plugin.addLineMetric(a, deviceInfo, service, lineFields)
+	}
+	if info.Status == "Up" {
+		tags := make(map[string]string)
+		tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+		tags["fritz_service"] = service.ShortServiceId()
+		plugin.addWANAccessTypeTag(tags, deviceInfo)
+		fields := make(map[string]interface{})
+		fields["upstream_curr_rate"] = info.UpstreamCurrRate
+		fields["downstream_curr_rate"] = info.DownstreamCurrRate
+		fields["upstream_max_rate"] = info.UpstreamMaxRate
+		fields["downstream_max_rate"] = info.DownstreamMaxRate
+		fields["upstream_noise_margin"] = info.UpstreamNoiseMargin
+		fields["downstream_noise_margin"] = info.DownstreamNoiseMargin
+		fields["upstream_attenuation"] = info.UpstreamAttenuation
+		fields["downstream_attenuation"] = info.DownstreamAttenuation
+		fields["upstream_power"] = info.UpstreamPower
+		fields["downstream_power"] = info.DownstreamPower
+		for key, value := range statisticsTotal.Statistics.fields() {
+			fields[key] = value
+		}
+		addOptionalTag(tags, "fritz_dsl_standard", info.StandardUsed)
+		addOptionalTag(tags, "fritz_dsl_line_encoding", info.LineEncoding)
+		addOptionalTag(tags, "fritz_dsl_data_path", info.DataPath)
+		addOptionalTag(tags, "fritz_dsl_atur_vendor", info.ATURVendor)
+		addOptionalTag(tags, "fritz_dsl_atuc_vendor", info.ATUCVendor)
+		addOptionalIntField(fields, "interleave_depth", info.InterleaveDepth)
+		addOptionalIntField(fields, "upstream_inp", info.UpstreamINP)
+		addOptionalIntField(fields, "downstream_inp", info.DownstreamINP)
+		addOptionalIntField(fields, "upstream_delay", info.UpstreamDelay)
+		addOptionalIntField(fields, "downstream_delay", info.DownstreamDelay)
+		a.AddCounter("fritzbox_dsl", fields, tags)
+		if plugin.GetDSLIntervalStatistics {
+			for _, interval := range dslStatisticsIntervals {
+				intervalStatistics := struct {
+					Body struct {
+						Statistics dslStatistics `xml:",any"`
+					} `xml:"Body"`
+				}{}
+				err = plugin.invokeDeviceService(deviceInfo, service, interval[1], &intervalStatistics)
+				if err != nil {
+					return err
+				}
+				intervalTags := make(map[string]string)
+				intervalTags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+				intervalTags["fritz_service"] = service.ShortServiceId()
+				plugin.addWANAccessTypeTag(intervalTags, deviceInfo)
+				intervalTags["fritz_dsl_interval"] = interval[0]
+				a.AddCounter("fritzbox_dsl_statistics", intervalStatistics.Body.Statistics.fields(), intervalTags)
+			}
+		}
+		if plugin.GetDSLSpectrum {
+			err = plugin.processDSLSpectrum(a, deviceInfo, service)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (plugin *FritzBox) processDSLSpectrum(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
+	var spectrum dslSpectrum
+	err := plugin.fetchWebData(deviceInfo, "dslSpectrum", &spectrum)
+	if err != nil {
+		return err
+	}
+	for portIndex, port := range spectrum.Data.Ports {
+		bands := port.getBands(plugin.DSLSpectrumBands)
+		for bandIndex, band := range bands {
+			tags := make(map[string]string)
+			tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+			tags["fritz_service"] = service.ShortServiceId()
+			plugin.addWANAccessTypeTag(tags, deviceInfo)
+			tags["fritz_dsl_spectrum_port"] = strconv.Itoa(portIndex)
+			tags["fritz_dsl_spectrum_band"] = fmt.Sprintf("%03d", bandIndex)
+			fields := make(map[string]interface{})
+			fields["tone_start"] = band.toneStart
+			fields["tone_end"] = band.toneEnd
+			if band.bits.count > 0 {
+				fields["bits_avg"] = band.bits.avg()
+				fields["bits_min"] = band.bits.min
+				fields["bits_max"] = band.bits.max
+			}
+			if band.snr.count > 0 {
+				fields["snr_avg"] = band.snr.avg()
+				fields["snr_min"] = band.snr.min
+				fields["snr_max"] = band.snr.max
+			}
+			a.AddCounter("fritzbox_dsl_spectrum", fields, tags)
+		}
+	}
+	return nil
+}
+
+var dslStatisticsIntervals = [][2]string{
+	{"showtime", "GetStatisticsShowtime"},
+	{"last_showtime", "GetStatisticsLastShowtime"},
+	{"current_day", "GetStatisticsCurrentDay"},
+	{"quarter_hour", "GetStatisticsQuarterHour"},
+}
+
+type dslStatistics struct {
+	ReceiveBlocks       uint `xml:"NewReceiveBlocks"`
+	TransmitBlocks      uint `xml:"NewTransmitBlocks"`
+	CellDelin           uint `xml:"NewCellDelin"`
+	LinkRetrain         uint `xml:"NewLinkRetrain"`
+	InitErrors          uint `xml:"NewInitErrors"`
+	InitTimeouts        uint `xml:"NewInitTimeouts"`
+	LossOfFraming       uint `xml:"NewLossOfFraming"`
+	ErroredSecs         uint `xml:"NewErroredSecs"`
+	SeverelyErroredSecs uint `xml:"NewSeverelyErroredSecs"`
+	FECErrors           uint `xml:"NewFECErrors"`
+	ATUCFECErrors       uint `xml:"NewATUCFECErrors"`
+	HECErrors           uint `xml:"NewHECErrors"`
+	ATUCHECErrors       uint `xml:"NewATUCHECErrors"`
+	CRCErrors           uint `xml:"NewCRCErrors"`
+	ATUCCRCErrors       uint `xml:"NewATUCCRCErrors"`
+}
+
+func (statistics *dslStatistics) fields() map[string]interface{} {
+	fields := make(map[string]interface{})
+	fields["receive_blocks"] = statistics.ReceiveBlocks
+	fields["transmit_blocks"] = statistics.TransmitBlocks
+	fields["cell_delin"] = statistics.CellDelin
+	fields["link_retrain"] = statistics.LinkRetrain
+	fields["init_errors"] = statistics.InitErrors
+	fields["init_timeouts"] = statistics.InitTimeouts
+	fields["loss_of_framing"] = statistics.LossOfFraming
+	fields["errored_secs"] = statistics.ErroredSecs
+	fields["severly_errored_secs"] = statistics.SeverelyErroredSecs
+	fields["fec_errors"] = statistics.FECErrors
+	fields["atuc_fec_errors"] = statistics.ATUCFECErrors
+	fields["hec_errors"] = statistics.HECErrors
+	fields["atuc_hec_errors"] = statistics.ATUCHECErrors
+	fields["crc_errors"] = statistics.CRCErrors
+	fields["atuc_crc_errors"] = statistics.ATUCCRCErrors
+	return fields
+}
+
+func (plugin *FritzBox) processDSLLinkConfigService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
+	info := struct {
+		Enable                 string `xml:"Body>GetInfoResponse>NewEnable"`
+		LinkStatus             string `xml:"Body>GetInfoResponse>NewLinkStatus"`
+		LinkType               string `xml:"Body>GetInfoResponse>NewLinkType"`
+		DestinationAddress     string `xml:"Body>GetInfoResponse>NewDestinationAddress"`
+		ATMEncapsulation       string `xml:"Body>GetInfoResponse>NewATMEncapsulation"`
+		ATMQoS                 string `xml:"Body>GetInfoResponse>NewATMQoS"`
+		ATMPeakCellRate        uint   `xml:"Body>GetInfoResponse>NewATMPeakCellRate"`
+		ATMSustainableCellRate uint   `xml:"Body>GetInfoResponse>NewATMSustainableCellRate"`
+	}{}
+	err := plugin.invokeDeviceService(deviceInfo, service, "GetInfo", &info)
+	if err != nil {
+		return err
+	}
+	statistics := struct {
+		ATMTransmittedBlocks uint `xml:"Body>GetStatisticsResponse>NewATMTransmittedBlocks"`
+		ATMReceivedBlocks    uint `xml:"Body>GetStatisticsResponse>NewATMReceivedBlocks"`
+		AAL5CRCErrors        uint `xml:"Body>GetStatisticsResponse>NewAAL5CRCErrors"`
+		ATMCRCErrors         uint `xml:"Body>GetStatisticsResponse>NewATMCRCErrors"`
+	}{}
+	err = plugin.invokeDeviceService(deviceInfo, service, "GetStatistics", &statistics)
+	if err != nil {
+		return err
+	}
+	if info.LinkStatus != "" {
+		tags := make(map[string]string)
+		tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+		tags["fritz_service"] = service.ShortServiceId()
+		plugin.addWANAccessTypeTag(tags, deviceInfo)
+		addOptionalTag(tags, "fritz_dsl_link_type", info.LinkType)
+		addOptionalTag(tags, "fritz_dsl_link_atm_encapsulation", info.ATMEncapsulation)
+		addOptionalTag(tags, "fritz_dsl_link_atm_qos", info.ATMQoS)
+		fields := make(map[string]interface{})
+		fields["enabled"] = info.Enable == "1"
+		fields["status"] = info.LinkStatus
+		fields["link_up"] = info.LinkStatus == "Up"
+		vpi, vci, found := parseDSLDestinationAddress(info.DestinationAddress)
+		if found {
+			fields["vpi"] = vpi
+			fields["vci"] = vci
+		}
+		fields["atm_peak_cell_rate"] = info.ATMPeakCellRate
+		fields["atm_sustainable_cell_rate"] = info.ATMSustainableCellRate
+		fields["atm_transmitted_blocks"] = statistics.ATMTransmittedBlocks
+		fields["atm_received_blocks"] = statistics.ATMReceivedBlocks
+		fields["aal5_crc_errors"] = statistics.AAL5CRCErrors
+		fields["atm_crc_errors"] = statistics.ATMCRCErrors
+		a.AddCounter("fritzbox_dsl_link", fields, tags)
+	}
+	return nil
+}
+
+func parseDSLDestinationAddress(destinationAddress string) (int, int, bool) {
+	pvc := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(destinationAddress), "PVC:"))
+	vpiVci := strings.SplitN(pvc, "/", 2)
+	if len(vpiVci) != 2 {
+		return 0, 0, false
+	}
+	vpi, err := strconv.Atoi(strings.TrimSpace(vpiVci[0]))
+	if err != nil {
+		return 0, 0, false
+	}
+	vci, err := strconv.Atoi(strings.TrimSpace(vpiVci[1]))
+	if err != nil {
+		return 0, 0, false
+	}
+	return vpi, vci, true
+}
+
+func addOptionalTag(tags map[string]string, key string, value string) {
+	if value != "" {
+		tags[key] = value
+	}
+}
+
+func addOptionalStringField(fields map[string]interface{}, key string, value string) {
+	if value != "" {
+		fields[key] = value
+	}
+}
+
+func addOptionalIntField(fields map[string]interface{}, key string, value string) {
+	intValue, err := strconv.Atoi(value)
+	if err == nil {
+		fields[key] = intValue
+	}
+}
+
+func (plugin *FritzBox) processPPPConnectionService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
+	info := struct {
+		ConnectionStatus     string `xml:"Body>GetInfoResponse>NewConnectionStatus"`
+		Uptime               uint   `xml:"Body>GetInfoResponse>NewUptime"`
+		UpstreamMaxBitRate   uint   `xml:"Body>GetInfoResponse>NewUpstreamMaxBitRate"`
+		DownstreamMaxBitRate uint   `xml:"Body>GetInfoResponse>NewDownstreamMaxBitRate"`
+	}{}
+	err := plugin.invokeDeviceService(deviceInfo, service, "GetInfo", &info)
+	if err != nil {
+		return err
+	}
+	if info.ConnectionStatus == "Connected" {
+		tags := make(map[string]string)
+		tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
 		tags["fritz_service"] = service.ShortServiceId()
+		plugin.addWANAccessTypeTag(tags, deviceInfo)
 		fields := make(map[string]interface{})
 		fields["uptime"] = info.Uptime
 		fields["upstream_max_bit_rate"] = info.UpstreamMaxBitRate
 		fields["downstream_max_bit_rate"] = info.DownstreamMaxBitRate
 		a.AddCounter("fritzbox_ppp", fields, tags)
+		plugin.processUptime(a, deviceInfo, service, "reconnect", info.Uptime, tags)
 	}
 	return nil
 }
 
-func (plugin *FritzBox) processHostsMeshService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
-	meshListPath := struct {
-		MeshListPath string `xml:"Body>X_AVM-DE_GetMeshListPathResponse>NewX_AVM-DE_MeshListPath"`
-	}{}
-	err := plugin.invokeDeviceService(deviceInfo, service, "X_AVM-DE_GetMeshListPath", &meshListPath)
+const ahaServiceId = "homeautoswitch"
+const ahaPath = "/webservices/homeautoswitch.lua"
+
+func (plugin *FritzBox) processAHAInfo(a telegraf.Accumulator, deviceInfo *deviceInfo) error {
+	var deviceList ahaDeviceList
+	err := plugin.fetchWebXML(deviceInfo, ahaPath, url.Values{"switchcmd": {"getdevicelistinfos"}}, &deviceList)
+	if errors.Is(err, errWebPageNotFound) {
+		if plugin.Debug {
+			plugin.Log.Infof("No AHA HTTP interface found for device: %s", deviceInfo.BaseUrl.Hostname())
+		}
+		return nil
+	}
 	if err != nil {
 		return err
 	}
+	for _, device := range deviceList.Devices {
+		plugin.processAHADevice(a, deviceInfo, &device, "device")
+		if plugin.GetAHAStats && device.Present != 0 && (device.PowerMeter != nil || device.Temperature != nil || device.Humidity != nil) {
+			a.AddError(plugin.processAHADeviceStats(a, deviceInfo, &device))
+		}
+	}
+	for _, group := range deviceList.Groups {
+		plugin.processAHADevice(a, deviceInfo, &group, "group")
+	}
+	var templateList ahaTemplateList
+	err = plugin.fetchWebXML(deviceInfo, ahaPath, url.Values{"switchcmd": {"gettemplatelistinfos"}}, &templateList)
+	if err != nil {
+		return err
+	}
+	for _, template := range templateList.Templates {
+		tags := make(map[string]string)
+		tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+		tags["fritz_service"] = ahaServiceId
+		tags["fritz_aha_ain"] = template.Identifier
+		tags["fritz_aha_name"] = template.Name
+		fields := make(map[string]interface{})
+		fields["function_bitmask"] = template.FunctionBitmask
+		fields["apply_mask"] = template.ApplyMask
+		fields["devices"] = len(template.Devices)
+		fields["sub_templates"] = len(template.SubTemplates)
+		a.AddCounter("fritzbox_aha_template", fields, tags)
+	}
+	return nil
+}
 
-	var meshList meshList
-
-	_, err = plugin.fetchJSON(deviceInfo.BaseUrl, meshListPath.MeshListPath, &meshList)
+func (plugin *FritzBox) processAHADeviceStats(a telegraf.Accumulator, deviceInfo *deviceInfo, device *ahaDevice) error {
+	var stats ahaDeviceStats
+	err := plugin.fetchWebXML(deviceInfo, ahaPath, url.Values{"switchcmd": {"getbasicdevicestats"}, "ain": {device.getAIN()}}, &stats)
 	if err != nil {
 		return err
 	}
+	now := time.Now()
+	for _, series := range stats.getSeries() {
+		grid := strconv.Itoa(series.values.Grid)
+		// The stats overlap between queries; only report samples not reported yet
+		latestKey := device.getAIN() + ":" + series.statsType + ":" + grid
+		latest := deviceInfo.ahaStatsLatest[latestKey]
+		for _, sample := range series.getSamples(now, latest) {
+			tags := make(map[string]string)
+			tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+			tags["fritz_service"] = ahaServiceId
+			tags["fritz_aha_ain"] = device.getAIN()
+			tags["fritz_aha_name"] = device.Name
+			addOptionalTag(tags, "fritz_aha_product", device.ProductName)
+			tags["fritz_aha_stats_type"] = series.statsType
+			tags["fritz_aha_stats_grid"] = grid
+			fields := make(map[string]interface{})
+			fields["value"] = sample.value
+			a.AddCounter("fritzbox_aha_stats", fields, tags, sample.timestamp)
+			latest = sample.timestamp
+		}
+		deviceInfo.ahaStatsLatest[latestKey] = latest
+	}
+	return nil
+}
+
+func (plugin *FritzBox) processAHADevice(a telegraf.Accumulator, deviceInfo *deviceInfo, device *ahaDevice, deviceType string) {
+	newTags := func() map[string]string {
+		tags := make(map[string]string)
+		tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+		tags["fritz_service"] = ahaServiceId
+		tags["fritz_aha_ain"] = device.getAIN()
+		tags["fritz_aha_name"] = device.Name
+		addOptionalTag(tags, "fritz_aha_product", device.ProductName)
+		return tags
+	}
+	deviceTags := newTags()
+	deviceTags["fritz_aha_type"] = deviceType
+	addOptionalTag(deviceTags, "fritz_aha_manufacturer", device.Manufacturer)
+	addOptionalTag(deviceTags, "fritz_aha_firmware", device.FwVersion)
+	deviceFields := make(map[string]interface{})
+	deviceFields["present"] = device.Present != 0
+	deviceFields["function_bitmask"] = device.FunctionBitmask
+	addAHABoolField(deviceFields, "tx_busy", device.TxBusy)
+	addAHAIntField(deviceFields, "battery", device.Battery)
+	addAHABoolField(deviceFields, "battery_low", device.BatteryLow)
+	if device.GroupInfo != nil {
+		deviceFields["members"] = len(device.GroupInfo.getMembers())
+		deviceFields["synchronized"] = device.Synchronized == "1"
+	}
+	a.AddCounter("fritzbox_aha_device", deviceFields, deviceTags)
+	if device.Present == 0 {
+		return
+	}
+	if device.Switch != nil {
+		fields := make(map[string]interface{})
+		addAHABoolField(fields, "state", device.Switch.State)
+		addOptionalStringField(fields, "mode", device.Switch.Mode)
+		addAHABoolField(fields, "lock", device.Switch.Lock)
+		addAHABoolField(fields, "device_lock", device.Switch.DeviceLock)
+		a.AddCounter("fritzbox_aha_switch", fields, newTags())
+	}
+	if device.PowerMeter != nil {
+		fields := make(map[string]interface{})
+		addAHAScaledField(fields, "voltage", device.PowerMeter.Voltage, 1000)
+		addAHAScaledField(fields, "power", device.PowerMeter.Power, 1000)
+		addAHAIntField(fields, "energy", device.PowerMeter.Energy)
+		a.AddCounter("fritzbox_aha_powermeter", fields, newTags())
+	}
+	if device.Temperature != nil {
+		fields := make(map[string]interface{})
+		addAHAScaledField(fields, "celsius", device.Temperature.Celsius, 10)
+		addAHAScaledField(fields, "offset", device.Temperature.Offset, 10)
+		a.AddCounter("fritzbox_aha_temperature", fields, newTags())
+	}
+	if device.Humidity != nil {
+		fields := make(map[string]interface{})
+		addAHAIntField(fields, "rel_humidity", device.Humidity.RelHumidity)
+		a.AddCounter("fritzbox_aha_humidity", fields, newTags())
+	}
+	if device.Thermostat != nil {
+		thermostat := device.Thermostat
+		fields := make(map[string]interface{})
+		addAHAThermostatField(fields, "current", thermostat.Tist)
+		addAHAThermostatField(fields, "target", thermostat.Tsoll)
+		addAHAThermostatField(fields, "comfort", thermostat.Komfort)
+		addAHAThermostatField(fields, "economy", thermostat.Absenk)
+		target, _ := parseAHAInt(thermostat.Tsoll)
+		fields["off"] = target == ahaThermostatOff
+		fields["on"] = target == ahaThermostatOn
+		addAHABoolField(fields, "window_open", thermostat.WindowOpenActive)
+		addAHABoolField(fields, "boost", thermostat.BoostActive)
+		addAHABoolField(fields, "summer", thermostat.SummerActive)
+		addAHABoolField(fields, "holiday", thermostat.HolidayActive)
+		addAHABoolField(fields, "adaptive_heating_running", thermostat.AdaptiveHeatingRunning)
+		addAHABoolField(fields, "lock", thermostat.Lock)
+		addAHABoolField(fields, "device_lock", thermostat.DeviceLock)
+		addAHAIntField(fields, "error_code", thermostat.ErrorCode)
+		addAHAIntField(fields, "battery", thermostat.Battery)
+		addAHABoolField(fields, "battery_low", thermostat.BatteryLow)
+		addAHAThermostatField(fields, "next_change_target", thermostat.NextChangeTchange)
+		addAHAIntField(fields, "next_change", thermostat.NextChangeEndPeriod)
+		a.AddCounter("fritzbox_aha_thermostat", fields, newTags())
+	}
+	for alertIndex, alert := range device.Alerts {
+		tags := newTags()
+		tags["fritz_aha_alert"] = strconv.Itoa(alertIndex)
+		fields := make(map[string]interface{})
+		addAHAIntField(fields, "state", alert.State)
+		addAHAIntField(fields, "last_change", alert.LastAlertChgTimestamp)
+		a.AddCounter("fritzbox_aha_alert", fields, tags)
+	}
+	for _, button := range device.Buttons {
+		tags := newTags()
+		tags["fritz_aha_button_ain"] = strings.ReplaceAll(button.Identifier, " ", "")
+		addOptionalTag(tags, "fritz_aha_button_name", button.Name)
+		fields := make(map[string]interface{})
+		addAHAIntField(fields, "last_pressed", button.LastPressedTimestamp)
+		addAHAIntField(fields, "battery", device.Battery)
+		addAHABoolField(fields, "battery_low", device.BatteryLow)
+		a.AddCounter("fritzbox_aha_button", fields, tags)
+	}
+	if device.Blind != nil {
+		fields := make(map[string]interface{})
+		addOptionalStringField(fields, "mode", device.Blind.Mode)
+		addAHABoolField(fields, "end_positions_set", device.Blind.EndPositionsSet)
+		if device.LevelControl != nil {
+			addAHAIntField(fields, "level", device.LevelControl.Level)
+			addAHAIntField(fields, "level_percentage", device.LevelControl.LevelPercentage)
+		}
+		a.AddCounter("fritzbox_aha_blind", fields, newTags())
+	} else if device.Switch == nil && (device.SimpleOnOff != nil || device.LevelControl != nil || device.ColorControl != nil) {
+		fields := make(map[string]interface{})
+		if device.SimpleOnOff != nil {
+			addAHABoolField(fields, "state", device.SimpleOnOff.State)
+		}
+		if device.LevelControl != nil {
+			addAHAIntField(fields, "level", device.LevelControl.Level)
+			addAHAIntField(fields, "level_percentage", device.LevelControl.LevelPercentage)
+		}
+		if device.ColorControl != nil {
+			addAHAIntField(fields, "color_mode", device.ColorControl.CurrentMode)
+			addAHAIntField(fields, "hue", device.ColorControl.Hue)
+			addAHAIntField(fields, "saturation", device.ColorControl.Saturation)
+			addAHAIntField(fields, "color_temperature", device.ColorControl.Temperature)
+		}
+		a.AddCounter("fritzbox_aha_lamp", fields, newTags())
+	}
+}
 
+func (plugin *FritzBox) processHostsMeshService(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService) error {
+	clientFilter, err := plugin.getMeshClientFilter()
+	if err != nil {
+		return err
+	}
+	meshList, err := plugin.fetchMeshList(deviceInfo, service)
+	if err != nil {
+		return err
+	}
+	var hostList *hostList
+	if plugin.DiscoverMeshSlaves || plugin.GetMeshNodes {
+		hostList, err = plugin.fetchHostList(deviceInfo, service)
+		a.AddError(err)
+	}
+	if plugin.DiscoverMeshSlaves && hostList != nil {
+		plugin.discoverMeshSlaves(deviceInfo, meshList, hostList)
+	}
+	if plugin.MeshTopologyDOTFile != "" || plugin.MeshTopologyJSONFile != "" {
+		plugin.meshTopologies[deviceInfo.BaseUrl.Hostname()] = newMeshTopology(deviceInfo.BaseUrl.Hostname(), meshList, clientFilter)
+	}
 	masterSlavePaths := meshList.getMasterSlavePaths()
+	meshLinks := make(map[string]*meshLinkHistory)
 	for _, masterSlavePath := range masterSlavePaths {
-		masterSlaveDataRates := masterSlavePath.getRoot().getDataRates()
+		masterSlaveDataRates := masterSlavePath.getUplinkPeer().getDataRates()
 		tags := make(map[string]string)
 		tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
 		tags["fritz_service"] = service.ShortServiceId()
 		tags["fritz_mesh_node_name"] = masterSlavePath.node.DeviceName
 		tags["fritz_mesh_node_type"] = masterSlavePath.nodeInterface.Type
 		tags["fritz_mesh_node_link"] = masterSlavePath.node.DeviceName + ":" + masterSlavePath.nodeInterface.Type + ":" + masterSlavePath.nodeInterface.Name
+		addMeshNodeTags(tags, masterSlavePath.node)
+		addMeshInterfaceTags(tags, masterSlavePath.nodeInterface)
 		fields := make(map[string]interface{})
 		fields["max_data_rate_rx"] = masterSlaveDataRates[0]
 		fields["max_data_rate_tx"] = masterSlaveDataRates[1]
 		fields["cur_data_rate_rx"] = masterSlaveDataRates[2]
 		fields["cur_data_rate_tx"] = masterSlaveDataRates[3]
+		addMeshInterfaceFields(fields, masterSlavePath.nodeInterface)
+		fields["utilization_rx"] = utilization(masterSlaveDataRates[2], masterSlaveDataRates[0])
+		fields["utilization_tx"] = utilization(masterSlaveDataRates[3], masterSlaveDataRates[1])
+		linkHistory := meshLinks[tags["fritz_mesh_node_link"]]
+		if linkHistory == nil {
+			linkHistory = deviceInfo.meshLinks[tags["fritz_mesh_node_link"]]
+			if linkHistory == nil {
+				linkHistory = &meshLinkHistory{}
+			}
+			linkHistory.update([2]int{masterSlaveDataRates[2], masterSlaveDataRates[3]}, plugin.MeshLinkWindow, plugin.MeshLinkDegradedRatio)
+			meshLinks[tags["fritz_mesh_node_link"]] = linkHistory
+		}
+		fields["degraded"] = linkHistory.isDegraded(plugin.MeshLinkDegradedCycles)
+		linkMin := linkHistory.min()
+		linkAvg := linkHistory.avg()
+		fields["min_data_rate_rx"] = linkMin[0]
+		fields["min_data_rate_tx"] = linkMin[1]
+		fields["avg_data_rate_rx"] = linkAvg[0]
+		fields["avg_data_rate_tx"] = linkAvg[1]
 		a.AddCounter("fritzbox_mesh", fields, tags)
 	}
+	deviceInfo.meshLinks = meshLinks
+	if plugin.GetMeshNodes {
+		plugin.processMeshNodes(a, deviceInfo, service, meshList, masterSlavePaths, hostList)
+	}
+	var clientPaths []*meshPath
+	if plugin.GetMeshClients || plugin.GetMeshClientEvents {
+		clientPaths = meshList.getClientPaths(clientFilter)
+	}
 	if plugin.GetMeshClients {
-		clientPaths := meshList.getClientPaths(plugin.MeshClientTypes)
 		for _, clientPath := range clientPaths {
 			clientDataRates := clientPath.getDataRates()
 			tags := make(map[string]string)
 			peer := clientPath.getRoot()
 			tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
 			tags["fritz_service"] = service.ShortServiceId()
-			tags["fritz_mesh_client_name"] = clientPath.node.DeviceName
+			tags["fritz_mesh_client_name"] = clientPath.getName()
 			tags["fritz_mesh_client_type"] = clientPath.nodeInterface.Type
 			tags["fritz_mesh_client_peer"] = peer.node.DeviceName
 			tags["fritz_mesh_client_link"] = peer.node.DeviceName + ":" + peer.nodeInterface.Type + ":" + peer.nodeInterface.Name
+			addMeshInterfaceTags(tags, peer.nodeInterface)
 			fields := make(map[string]interface{})
 			fields["max_data_rate_rx"] = clientDataRates[0]
 			fields["max_data_rate_tx"] = clientDataRates[1]
 			fields["cur_data_rate_rx"] = clientDataRates[2]
 			fields["cur_data_rate_tx"] = clientDataRates[3]
+			addMeshInterfaceFields(fields, peer.nodeInterface)
+			uplinkPath := meshList.getUplinkPath(peer.node, masterSlavePaths)
+			if uplinkPath != nil {
+				meshNodeNames := uplinkPath.getMeshNodeNames()
+				uplinkDataRates := uplinkPath.getUplinkDataRates()
+				tags["fritz_mesh_client_path"] = strings.Join(meshNodeNames, ">")
+				fields["hop_count"] = len(meshNodeNames)
+				fields["bottleneck_data_rate_rx"] = minDataRate(clientDataRates[2], uplinkDataRates[0])
+				fields["bottleneck_data_rate_tx"] = minDataRate(clientDataRates[3], uplinkDataRates[1])
+			}
 			a.AddCounter("fritzbox_mesh_client", fields, tags)
 		}
 	}
+	if plugin.GetMeshClientEvents {
+		plugin.processMeshClientEvents(a, deviceInfo, service, clientPaths)
+	}
 	return nil
 }
 
+func (plugin *FritzBox) processMeshNodes(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService, meshList *meshList, masterSlavePaths []*meshPath, hostList *hostList) {
+	clientCounts := countClientsByType(meshList.getClientPaths(&meshClientFilter{reportUnnamed: true}))
+	for _, node := range meshList.getMeshNodes() {
+		tags := make(map[string]string)
+		tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+		tags["fritz_service"] = service.ShortServiceId()
+		tags["fritz_mesh_node_name"] = node.DeviceName
+		tags["fritz_mesh_node_role"] = node.MeshRole
+		addMeshNodeTags(tags, node)
+		if node.isSlave() {
+			uplinkPath := meshList.getUplinkPath(node, masterSlavePaths)
+			if uplinkPath != nil {
+				tags["fritz_mesh_node_uplink_type"] = uplinkPath.nodeInterface.Type
+			}
+		}
+		fields := make(map[string]interface{})
+		if hostList != nil {
+			updateAvailable, found := hostList.lookupUpdateAvailable(node.getMacAddresses())
+			if found {
+				fields["update_available"] = updateAvailable
+			}
+		}
+		clients := 0
+		for _, clientType := range []string{"LAN", "WLAN", "PLC"} {
+			fields["clients_"+strings.ToLower(clientType)] = 0
+		}
+		for clientType, clientCount := range clientCounts[node.Uid] {
+			fields["clients_"+strings.ToLower(clientType)] = clientCount
+			clients += clientCount
+		}
+		fields["clients"] = clients
+		a.AddCounter("fritzbox_mesh_node", fields, tags)
+	}
+}
+
+func addMeshNodeTags(tags map[string]string, node *meshListNode) {
+	if node.DeviceModel != "" {
+		tags["fritz_mesh_node_model"] = node.DeviceModel
+	}
+	if node.DeviceFirmwareVersion != "" {
+		tags["fritz_mesh_node_firmware"] = node.DeviceFirmwareVersion
+	}
+}
+
+func addMeshInterfaceTags(tags map[string]string, nodeInterface *meshListNodeInterface) {
+	band := nodeInterface.getBand()
+	if band != "" {
+		tags["fritz_mesh_band"] = band
+	}
+}
+
+func addMeshInterfaceFields(fields map[string]interface{}, nodeInterface *meshListNodeInterface) {
+	if nodeInterface.CurrentChannel > 0 {
+		fields["channel"] = nodeInterface.CurrentChannel
+	}
+}
+
+func (plugin *FritzBox) processMeshClientEvents(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService, clientPaths []*meshPath) {
+	now := time.Now()
+	meshClients := make(map[string]*meshClientState)
+	for _, clientPath := range clientPaths {
+		clientKey := clientPath.getClientKey()
+		if meshClients[clientKey] != nil {
+			continue
+		}
+		peer := clientPath.getRoot()
+		meshClients[clientKey] = &meshClientState{
+			name:  clientPath.getName(),
+			peer:  peer.node.DeviceName,
+			link:  peer.node.DeviceName + ":" + peer.nodeInterface.Type + ":" + peer.nodeInterface.Name,
+			since: now,
+		}
+	}
+	if deviceInfo.meshClients != nil {
+		for clientKey, meshClient := range meshClients {
+			previousMeshClient := deviceInfo.meshClients[clientKey]
+			if previousMeshClient == nil {
+				plugin.addMeshClientEvent(a, deviceInfo, service, "connect", nil, meshClient)
+			} else if previousMeshClient.link != meshClient.link {
+				plugin.addMeshClientEvent(a, deviceInfo, service, "roam", previousMeshClient, meshClient)
+			} else {
+				meshClient.since = previousMeshClient.since
+			}
+		}
+		for clientKey, previousMeshClient := range deviceInfo.meshClients {
+			if meshClients[clientKey] == nil {
+				plugin.addMeshClientEvent(a, deviceInfo, service, "disconnect", previousMeshClient, nil)
+			}
+		}
+	}
+	deviceInfo.meshClients = meshClients
+}
+
+func (plugin *FritzBox) addMeshClientEvent(a telegraf.Accumulator, deviceInfo *deviceInfo, service *tr64DescDeviceService, event string, oldMeshClient *meshClientState, newMeshClient *meshClientState) {
+	tags := make(map[string]string)
+	tags["fritz_device"] = deviceInfo.BaseUrl.Hostname()
+	tags["fritz_service"] = service.ShortServiceId()
+	tags["fritz_mesh_event"] = event
+	fields := make(map[string]interface{})
+	fields["dwell_time"] = uint(0)
+	if oldMeshClient != nil {
+		tags["fritz_mesh_client_name"] = oldMeshClient.name
+		tags["fritz_mesh_client_old_peer"] = oldMeshClient.peer
+		tags["fritz_mesh_client_old_link"] = oldMeshClient.link
+		fields["dwell_time"] = uint(time.Since(oldMeshClient.since).Seconds())
+	}
+	if newMeshClient != nil {
+		tags["fritz_mesh_client_name"] = newMeshClient.name
+		tags["fritz_mesh_client_new_peer"] = newMeshClient.peer
+		tags["fritz_mesh_client_new_link"] = newMeshClient.link
+	}
+	a.AddFields("fritzbox_mesh_event", fields, tags)
+}
+
+func (plugin *FritzBox) getMeshClientFilter() (*meshClientFilter, error) {
+	if plugin.meshClientFilter == nil {
+		clientFilter := &meshClientFilter{
+			clientTypes:   plugin.MeshClientTypes,
+			reportUnnamed: plugin.MeshClientReportUnnamed,
+		}
+		patternLists := []struct {
+			option    string
+			patterns  []string
+			upperCase bool
+			filter    *filter.Filter
+		}{
+			{"mesh_client_name_include", plugin.MeshClientNameInclude, false, &clientFilter.nameInclude},
+			{"mesh_client_name_exclude", plugin.MeshClientNameExclude, false, &clientFilter.nameExclude},
+			{"mesh_client_mac_include", plugin.MeshClientMACInclude, true, &clientFilter.macInclude},
+			{"mesh_client_mac_exclude", plugin.MeshClientMACExclude, true, &clientFilter.macExclude},
+			{"mesh_client_interface_include", plugin.MeshClientInterfaceInclude, false, &clientFilter.interfaceInclude},
+			{"mesh_client_interface_exclude", plugin.MeshClientInterfaceExclude, false, &clientFilter.interfaceExclude},
+		}
+		for _, patternList := range patternLists {
+			compiledFilter, err := compilePatternFilter(patternList.patterns, patternList.upperCase)
+			if err != nil {
+				return nil, fmt.Errorf("fritzbox: Invalid %s pattern (cause: %v)", patternList.option, err)
+			}
+			*patternList.filter = compiledFilter
+		}
+		plugin.meshClientFilter = clientFilter
+	}
+	return plugin.meshClientFilter, nil
+}
+
+func (plugin *FritzBox) fetchMeshList(deviceInfo *deviceInfo, service *tr64DescDeviceService) (*meshList, error) {
+	meshListPath := struct {
+		MeshListPath string `xml:"Body>X_AVM-DE_GetMeshListPathResponse>NewX_AVM-DE_MeshListPath"`
+	}{}
+	err := plugin.invokeDeviceService(deviceInfo, service, "X_AVM-DE_GetMeshListPath", &meshListPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var meshList meshList
+
+	_, err = plugin.fetchJSON(deviceInfo.BaseUrl, meshListPath.MeshListPath, &meshList)
+	if err != nil {
+		return nil, err
+	}
+	if !meshList.isSupportedSchema() && deviceInfo.meshSchemaWarned != meshList.SchemaVersion {
+		plugin.Log.Warnf("Unsupported mesh list schema version %s for device: %s (expected %s.x)", meshList.SchemaVersion, deviceInfo.BaseUrl.Hostname(), meshListSupportedSchemaMajor)
+		deviceInfo.meshSchemaWarned = meshList.SchemaVersion
+	}
+	return &meshList, nil
+}
+
+func (plugin *FritzBox) fetchHostList(deviceInfo *deviceInfo, service *tr64DescDeviceService) (*hostList, error) {
+	hostListPath := struct {
+		HostListPath string `xml:"Body>X_AVM-DE_GetHostListPathResponse>NewX_AVM-DE_HostListPath"`
+	}{}
+	err := plugin.invokeDeviceService(deviceInfo, service, "X_AVM-DE_GetHostListPath", &hostListPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var hostList hostList
+
+	_, err = plugin.fetchXML(deviceInfo.BaseUrl, hostListPath.HostListPath, &hostList)
+	if err != nil {
+		return nil, err
+	}
+	return &hostList, nil
+}
+
+func (plugin *FritzBox) discoverMeshSlaves(deviceInfo *deviceInfo, meshList *meshList, hostList *hostList) {
+	configuredHosts := plugin.getConfiguredHosts()
+	discoveredDevices := make([][]string, 0)
+	for _, slaveNode := range meshList.getSlaveNodes() {
+		ipAddress := hostList.lookupIPAddress(slaveNode.getMacAddresses())
+		if ipAddress == "" {
+			if plugin.Debug {
+				plugin.Log.Infof("No address found for mesh slave: %s", slaveNode.DeviceName)
+			}
+			continue
+		}
+		// Skip slaves already configured (via their address or any of their names)
+		slaveHosts := []string{ipAddress, slaveNode.DeviceName}
+		for _, macAddress := range slaveNode.getMacAddresses() {
+			host := hostList.lookupHost(macAddress)
+			if host != nil && host.HostName != "" {
+				slaveHosts = append(slaveHosts, host.HostName)
+			}
+		}
+		if isConfiguredHost(configuredHosts, slaveHosts) {
+			continue
+		}
+		slaveBaseUrl := *deviceInfo.BaseUrl
+		if deviceInfo.BaseUrl.Port() != "" {
+			slaveBaseUrl.Host = net.JoinHostPort(ipAddress, deviceInfo.BaseUrl.Port())
+		} else {
+			slaveBaseUrl.Host = ipAddress
+		}
+		login, password := plugin.getMeshSlaveCredentials(deviceInfo, slaveNode.DeviceName, ipAddress)
+		if plugin.Debug {
+			plugin.Log.Infof("Discovered mesh slave: %s (%s)", slaveNode.DeviceName, slaveBaseUrl.String())
+		}
+		discoveredDevices = append(discoveredDevices, []string{slaveBaseUrl.String(), login, password})
+	}
+	// Drop the cached infos of previously discovered slaves which left the mesh
+	for _, previousDevice := range plugin.discoveredDevices[deviceInfo.BaseUrl.Hostname()] {
+		if !slices.ContainsFunc(discoveredDevices, func(device []string) bool { return device[0] == previousDevice[0] }) {
+			if plugin.Debug {
+				plugin.Log.Infof("Mesh slave left: %s", previousDevice[0])
+			}
+			delete(plugin.deviceInfos, previousDevice[0])
+		}
+	}
+	plugin.discoveredDevices[deviceInfo.BaseUrl.Hostname()] = discoveredDevices
+}
+
+// getConfiguredHosts gets the host names of the configured devices (in lower case) as well as the
+// addresses they resolve to. As the configured devices do not change, they are resolved only once.
+func (plugin *FritzBox) getConfiguredHosts() map[string]bool {
+	if plugin.configuredHosts == nil {
+		configuredHosts := make(map[string]bool)
+		for _, device := range plugin.Devices {
+			if len(device) != 3 {
+				continue
+			}
+			configuredUrl, err := url.Parse(device[0])
+			if err != nil || configuredUrl.Hostname() == "" {
+				continue
+			}
+			configuredHost := strings.ToLower(configuredUrl.Hostname())
+			configuredHosts[configuredHost] = true
+			addresses, err := plugin.lookupHost(configuredHost)
+			if err != nil {
+				if plugin.Debug {
+					plugin.Log.Infof("Failed to resolve configured device %s (cause: %v)", configuredHost, err)
+				}
+				continue
+			}
+			for _, address := range addresses {
+				configuredHosts[address] = true
+			}
+		}
+		plugin.configuredHosts = configuredHosts
+	}
+	return plugin.configuredHosts
+}
+
+func isConfiguredHost(configuredHosts map[string]bool, hosts []string) bool {
+	for _, host := range hosts {
+		if configuredHosts[strings.ToLower(host)] {
+			return true
+		}
+	}
+	return false
+}
+
+func (plugin *FritzBox) getMeshSlaveCredentials(deviceInfo *deviceInfo, deviceName string, ipAddress string) (string, string) {
+	for _, key := range []string{deviceName, ipAddress} {
+		credentials, found := plugin.MeshSlaveCredentials[key]
+		if found && len(credentials) == 2 {
+			return credentials[0], credentials[1]
+		}
+	}
+	return deviceInfo.Login, deviceInfo.Password
+}
+
 func (plugin *FritzBox) invokeDeviceService(deviceInfo *deviceInfo, service *tr64DescDeviceService, action string, out interface{}) error {
 	controlUrl, err := url.Parse(service.ControlURL)
 	if err != nil {
@@ -639,11 +2410,15 @@ func (plugin *FritzBox) fetchDeviceInfo(rawBaseUrl string, login string, passwor
 			}
 		}
 		cachedDeviceInfo = &deviceInfo{
-			BaseUrl:     baseUrl,
-			Login:       login,
-			Password:    password,
-			GetMeshInfo: getMeshInfo,
-			ServiceInfo: &serviceInfo}
+			BaseUrl:             baseUrl,
+			Login:               login,
+			Password:            password,
+			GetMeshInfo:         getMeshInfo,
+			ServiceInfo:         &serviceInfo,
+			wlanStates:          make(map[string]*wlanState),
+			onlineMonitorStates: make(map[string]*onlineMonitorState),
+			uptimeSamples:       make(map[string]*uptimeSample),
+			ahaStatsLatest:      make(map[string]time.Time)}
 		plugin.deviceInfos[rawBaseUrl] = cachedDeviceInfo
 	}
 	return cachedDeviceInfo, nil
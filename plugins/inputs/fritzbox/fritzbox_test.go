@@ -7,6 +7,7 @@
 package fritzbox
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"log"
@@ -14,8 +15,11 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
@@ -40,27 +44,1040 @@ func TestDescription(t *testing.T) {
 
 func TestGather1(t *testing.T) {
 	testServerHandler := &testServerHandler{Debug: true}
+	plugin, testServerURL := newTestPlugin(t, testServerHandler)
+	plugin.GetMeshInfo = []string{testServerURL.Hostname()}
+	plugin.GetMeshClients = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.True(t, a.HasMeasurement("fritzbox_device"))
+	require.True(t, a.HasMeasurement("fritzbox_wlan"))
+	require.True(t, a.HasMeasurement("fritzbox_wan"))
+	require.True(t, a.HasMeasurement("fritzbox_dsl"))
+	require.True(t, a.HasMeasurement("fritzbox_ppp"))
+	require.True(t, a.HasMeasurement("fritzbox_mesh"))
+	require.True(t, a.HasMeasurement("fritzbox_mesh_client"))
+	require.Equal(t, "master1", a.TagValue("fritzbox_mesh_client", "fritz_mesh_client_path"))
+	hopCount, _ := a.IntField("fritzbox_mesh_client", "hop_count")
+	require.Equal(t, 1, hopCount)
+	bottleneckDataRateRx, _ := a.IntField("fritzbox_mesh_client", "bottleneck_data_rate_rx")
+	require.Equal(t, 72000, bottleneckDataRateRx)
+	require.Equal(t, "FRITZ!Repeater 3000", a.TagValue("fritzbox_mesh", "fritz_mesh_node_model"))
+	require.Equal(t, "181.07.57", a.TagValue("fritzbox_mesh", "fritz_mesh_node_firmware"))
+	require.Equal(t, "2G", a.TagValue("fritzbox_mesh_client", "fritz_mesh_band"))
+	channel, _ := a.IntField("fritzbox_mesh_client", "channel")
+	require.Equal(t, 6, channel)
+}
+
+func TestGatherDeviceInfo(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, "154.07.57", a.TagValue("fritzbox_device", "fritz_device_software_version"))
+	serialNumber, _ := a.StringField("fritzbox_device", "serial_number")
+	require.Equal(t, "3431C4123456", serialNumber)
+	provisioningCode, _ := a.StringField("fritzbox_device", "provisioning_code")
+	require.Equal(t, "000.000.000.000", provisioningCode)
+	require.False(t, a.HasMeasurement("fritzbox_firmware"))
+}
+
+func TestGatherFirmwareInfo(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetFirmwareInfo = true
+	plugin.FullQueryCycle = 1
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, "154.07.57", a.TagValue("fritzbox_firmware", "fritz_firmware_version"))
+	require.Equal(t, "Test Model 1", a.TagValue("fritzbox_firmware", "fritz_device_model"))
+	firmwares := gatheredMetrics(&a, "fritzbox_firmware")
+	require.Equal(t, 1, len(firmwares))
+	require.Equal(t, "154.07.57", firmwares[0].Fields["current_version"])
+	require.Equal(t, true, firmwares[0].Fields["update_available"])
+	require.Equal(t, "154.07.59", firmwares[0].Fields["available_version"])
+	require.Equal(t, "check", firmwares[0].Fields["auto_update_mode"])
+	require.Equal(t, 7, firmwares[0].Fields["update_check_interval"])
+	// Device info is queried only once
+	require.Equal(t, 1, testServerHandler.DeviceInfos)
+
+	// Device info is queried explicitly, if not already queried by the DeviceInfo service
+	plugin.GetDeviceInfo = false
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, "154.07.57", a.TagValue("fritzbox_firmware", "fritz_firmware_version"))
+	require.Equal(t, 2, testServerHandler.DeviceInfos)
+}
+
+func TestGatherDeviceLog(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetDeviceLog = true
+	plugin.GetOnlineMonitor = true
+	plugin.FullQueryCycle = 1
+
+	var a testutil.Accumulator
+
+	// The entries already logged are skipped
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 0, len(gatheredMetrics(&a, "fritzbox_log")))
+
+	// Identical entries within the same second are reported individually
+	testServerHandler.DeviceLog = testDeviceLog2
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	logs := gatheredMetrics(&a, "fritzbox_log")
+	require.Equal(t, 3, len(logs))
+	require.Equal(t, 501, logs[0].Fields["message_id"])
+	require.Equal(t, 501, logs[1].Fields["message_id"])
+	require.Equal(t, logs[0].Time, logs[1].Time)
+	require.Equal(t, 24, logs[2].Fields["message_id"])
+
+	// Already reported entries are skipped
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 0, len(gatheredMetrics(&a, "fritzbox_log")))
+	// The device's clock is queried once per full query (shared by device log and online monitor)
+	require.Equal(t, 3, testServerHandler.TimeInfos)
+}
+
+func TestGatherDeviceLogBacklog(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetDeviceLog = true
+	plugin.DeviceLogBacklog = true
+	plugin.FullQueryCycle = 1
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	logs := gatheredMetrics(&a, "fritzbox_log")
+	require.Equal(t, 5, len(logs))
+	require.Equal(t, "dsl", logs[0].Tags["fritz_log_category"])
+	require.Equal(t, 22, logs[0].Fields["message_id"])
+	require.Equal(t, "DSL antwortet nicht (Keine DSL-Synchronisierung).", logs[0].Fields["message"])
+	require.Equal(t, "net", logs[4].Tags["fritz_log_category"])
+	require.True(t, logs[0].Time.Before(logs[4].Time))
+	_, deviceOffset := time.Now().Zone()
+	require.Equal(t, time.Date(2024, 2, 1, 11, 57, 30, 0, time.FixedZone("", deviceOffset)).Unix(), logs[0].Time.Unix())
+
+	// Already reported entries are skipped
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 0, len(gatheredMetrics(&a, "fritzbox_log")))
+}
+
+func TestGatherUptimeEvents(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetPPPInfo = true
+	plugin.FullQueryCycle = 1
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 0, len(gatheredMetrics(&a, "fritzbox_event")))
+
+	// Decreasing uptimes indicate a reboot and a reconnect
+	testServerHandler.Restarted = true
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	events := gatheredMetrics(&a, "fritzbox_event")
+	require.Equal(t, 2, len(events))
+	eventTypes := gatheredMetricsByTags(&a, "fritzbox_event", "fritz_event")
+	require.Contains(t, eventTypes, "reboot")
+	require.Equal(t, "DeviceInfo1", eventTypes["reboot"].Tags["fritz_service"])
+	require.Equal(t, uint(751513), eventTypes["reboot"].Fields["previous_uptime"])
+	require.Equal(t, uint(42), eventTypes["reboot"].Fields["uptime"])
+	require.Contains(t, eventTypes["reboot"].Fields, "outage_duration")
+	require.Contains(t, eventTypes, "reconnect")
+	require.Equal(t, "WANPPPConnection1", eventTypes["reconnect"].Tags["fritz_service"])
+	require.Equal(t, uint(755581), eventTypes["reconnect"].Fields["previous_uptime"])
+
+	// Increasing uptimes do not
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 0, len(gatheredMetrics(&a, "fritzbox_event")))
+}
+
+func TestGatherTimeInfo(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetTimeInfo = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	times := gatheredMetrics(&a, "fritzbox_time")
+	require.Equal(t, 1, len(times))
+	require.Equal(t, "Time1", times[0].Tags["fritz_service"])
+	require.Equal(t, "ntp.example.org", times[0].Fields["ntp_server1"])
+	require.NotContains(t, times[0].Fields, "ntp_server2")
+	require.NotContains(t, times[0].Fields, "local_time_zone")
+	require.Equal(t, true, times[0].Fields["daylight_savings_used"])
+	require.InDelta(t, 120.0, times[0].Fields["clock_offset"], 2.0)
+	require.NotContains(t, times[0].Fields, "synchronized")
+}
+
+func TestParseTimeInfoLocalTime(t *testing.T) {
+	localTime, err := parseTimeInfoLocalTime("2024-02-01T12:05:10+01:00")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 2, 1, 11, 5, 10, 0, time.UTC), localTime.UTC())
+	localTime, err = parseTimeInfoLocalTime("2024-02-01T12:05:10")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 2, 1, 12, 5, 10, 0, time.Local), localTime)
+	_, err = parseTimeInfoLocalTime("")
+	require.Error(t, err)
+}
+
+func TestGatherDeviceLogTimeZone(t *testing.T) {
+	// Device time zone differs from host time zone
+	_, hostOffset := time.Now().Zone()
+	deviceLocation := time.FixedZone("", hostOffset+5*3600+1800)
+	testServerHandler := &testServerHandler{Debug: true, TimeLocation: deviceLocation}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetDeviceLog = true
+	plugin.DeviceLogBacklog = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	logs := gatheredMetrics(&a, "fritzbox_log")
+	require.Equal(t, 5, len(logs))
+	require.Equal(t, time.Date(2024, 2, 1, 11, 57, 30, 0, deviceLocation).Unix(), logs[0].Time.Unix())
+}
+
+func TestGatherDeviceLogText(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true, DeviceLogText: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetDeviceLog = true
+	plugin.DeviceLogBacklog = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	logs := gatheredMetrics(&a, "fritzbox_log")
+	require.Equal(t, 3, len(logs))
+	require.NotContains(t, logs[0].Tags, "fritz_log_category")
+	require.NotContains(t, logs[0].Fields, "message_id")
+	require.Equal(t, "DSL antwortet nicht (Keine DSL-Synchronisierung).", logs[0].Fields["message"])
+}
+
+func TestGatherWLANBand(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	wlans := gatheredMetricsByTags(&a, "fritzbox_wlan", "fritz_service")
+	require.Equal(t, 2, len(wlans))
+	wlan2 := wlans["WLANConfiguration2"]
+	require.Equal(t, "6G", wlan2.Tags["fritz_wlan_band"])
+	require.True(t, strings.HasSuffix(wlan2.Tags["fritz_wlan_network"], ":TestSSID2:6G"))
+	require.Equal(t, "true", wlan2.Tags["fritz_wlan_guest"])
+	require.Equal(t, "ax", wlan2.Tags["fritz_wlan_standard"])
+	require.Equal(t, "160", wlan2.Tags["fritz_wlan_bandwidth"])
+	require.Equal(t, "true", wlan2.Tags["fritz_wlan_autochannel"])
+	wlan3 := wlans["WLANConfiguration3"]
+	require.Equal(t, "2G", wlan3.Tags["fritz_wlan_band"])
+	require.Equal(t, "false", wlan3.Tags["fritz_wlan_guest"])
+	require.Equal(t, "", wlan3.Tags["fritz_wlan_standard"])
+}
+
+func TestGatherWLANBandUnknown(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true, WLAN3Channel: "0"}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	wlan3 := gatheredMetricsByTags(&a, "fritzbox_wlan", "fritz_service")["WLANConfiguration3"]
+	// Neither frequency band nor channel identify the band; fall back to the previous derivation
+	require.Equal(t, "2G", wlan3.Tags["fritz_wlan_band"])
+	require.True(t, strings.HasSuffix(wlan3.Tags["fritz_wlan_network"], ":TestSSID3:2G"))
+}
+
+func TestGatherWLANRadioInfo(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetWLANRadioInfo = true
+	plugin.GetWLANNeighbours = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	radios := gatheredMetricsByTags(&a, "fritzbox_wlan_radio", "fritz_service")
+	neighbours := gatheredMetricsByTags(&a, "fritzbox_wlan_neighbour", "fritz_wlan_neighbour_ssid")
+	require.Equal(t, 2, len(radios))
+	radio2 := radios["WLANConfiguration2"]
+	require.Equal(t, uint64(1001), radio2.Fields["packets_sent"])
+	require.Equal(t, uint64(2002), radio2.Fields["packets_received"])
+	require.Equal(t, 37, radio2.Fields["channel"])
+	require.Equal(t, 160, radio2.Fields["channel_width"])
+	require.Equal(t, 100, radio2.Fields["transmit_power"])
+	require.Equal(t, true, radio2.Fields["autochannel"])
+	require.Equal(t, 2, radio2.Fields["neighbours"])
+	require.Equal(t, 1, radio2.Fields["neighbours_on_channel"])
+	radio3 := radios["WLANConfiguration3"]
+	require.Equal(t, uint64(0), radio3.Fields["packets_sent"])
+	require.Equal(t, 0, radio3.Fields["neighbours"])
+	require.Equal(t, 2, len(neighbours))
+	require.Equal(t, -62, neighbours["Neighbour1"].Fields["rssi"])
+	require.Equal(t, 37, neighbours["Neighbour1"].Fields["channel"])
+	require.Equal(t, "5G", neighbours["Neighbour2"].Tags["fritz_wlan_neighbour_band"])
+}
+
+func TestGatherWLANRadioInfoWithoutNeighbours(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true, NoWLANNeighbours: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetWLANRadioInfo = true
+	plugin.GetWLANNeighbours = true
+
+	var a testutil.Accumulator
+
+	require.Error(t, a.GatherError(plugin.Gather))
+	radios := gatheredMetricsByTags(&a, "fritzbox_wlan_radio", "fritz_service")
+	require.Equal(t, 2, len(radios))
+	require.Equal(t, uint64(1001), radios["WLANConfiguration2"].Fields["packets_sent"])
+	require.NotContains(t, radios["WLANConfiguration2"].Fields, "neighbours")
+	require.False(t, a.HasMeasurement("fritzbox_wlan_neighbour"))
+}
+
+func TestGatherWLANState(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetWLANState = true
+	plugin.FullQueryCycle = 1
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	wlanStates := gatheredMetricsByTags(&a, "fritzbox_wlan_state", "fritz_service")
+	require.Equal(t, 3, len(wlanStates))
+	require.Equal(t, false, wlanStates["WLANConfiguration1"].Fields["enabled"])
+	require.Equal(t, true, wlanStates["WLANConfiguration2"].Fields["enabled"])
+	require.Equal(t, true, wlanStates["WLANConfiguration2"].Fields["night_control"])
+	require.Equal(t, "true", wlanStates["WLANConfiguration2"].Tags["fritz_wlan_guest"])
+	require.Equal(t, false, wlanStates["WLANConfiguration3"].Fields["night_control"])
+	require.False(t, a.HasMeasurement("fritzbox_wlan_event"))
+
+	testServerHandler.WLAN1Enabled = true
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	wlanStates = gatheredMetricsByTags(&a, "fritzbox_wlan_state", "fritz_service")
+	require.Equal(t, true, wlanStates["WLANConfiguration1"].Fields["enabled"])
+	require.Equal(t, "enable", a.TagValue("fritzbox_wlan_event", "fritz_wlan_event"))
+	require.Equal(t, "WLANConfiguration1", a.TagValue("fritzbox_wlan_event", "fritz_service"))
+
+	testServerHandler.WLAN1Enabled = false
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, "disable", a.TagValue("fritzbox_wlan_event", "fritz_wlan_event"))
+}
+
+func TestGatherLANInfo(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetLANInfo = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	lanPorts := gatheredMetrics(&a, "fritzbox_lan_port")
+	require.Equal(t, 1, len(lanPorts))
+	lanInterface := lanPorts[0]
+	require.Equal(t, map[string]string{"fritz_device": "127.0.0.1", "fritz_service": "LANEthernetInterfaceConfig1"}, lanInterface.Tags)
+	require.Equal(t, "Up", lanInterface.Fields["status"])
+	require.Equal(t, "Full", lanInterface.Fields["duplex_mode"])
+	require.Equal(t, true, lanInterface.Fields["enabled"])
+	require.Equal(t, 1000, lanInterface.Fields["configured_max_bit_rate"])
+	require.Equal(t, uint64(987654321), lanInterface.Fields["bytes_received"])
+	require.Equal(t, uint64(12345), lanInterface.Fields["packets_sent"])
+	lanLinks := gatheredMetrics(&a, "fritzbox_lan_link")
+	require.Equal(t, 1, len(lanLinks))
+	require.Equal(t, "LAN:2", lanLinks[0].Tags["fritz_lan_port"])
+	require.Equal(t, "Hosts1", lanLinks[0].Tags["fritz_service"])
+	require.Equal(t, 100, lanLinks[0].Fields["link_speed"])
+	require.Equal(t, 1, lanLinks[0].Fields["hosts"])
+}
+
+func TestGatherDSLInfo(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, "G.993.2_Annex_B", a.TagValue("fritzbox_dsl", "fritz_dsl_standard"))
+	require.Equal(t, "Interleaved", a.TagValue("fritzbox_dsl", "fritz_dsl_data_path"))
+	require.Equal(t, "BDCM", a.TagValue("fritzbox_dsl", "fritz_dsl_atuc_vendor"))
+	downstreamINP, _ := a.IntField("fritzbox_dsl", "downstream_inp")
+	require.Equal(t, 46, downstreamINP)
+	downstreamDelay, _ := a.IntField("fritzbox_dsl", "downstream_delay")
+	require.Equal(t, 8, downstreamDelay)
+	require.True(t, a.HasMeasurement("fritzbox_dsl_link"))
+	dslLinks := gatheredMetrics(&a, "fritzbox_dsl_link")
+	require.Equal(t, 1, len(dslLinks))
+	require.NotContains(t, dslLinks[0].Tags, "fritz_dsl_link_status")
+	require.Equal(t, "Up", dslLinks[0].Fields["status"])
+	require.Equal(t, true, dslLinks[0].Fields["link_up"])
+	require.Equal(t, "LLC", a.TagValue("fritzbox_dsl_link", "fritz_dsl_link_atm_encapsulation"))
+	vpi, _ := a.IntField("fritzbox_dsl_link", "vpi")
+	require.Equal(t, 1, vpi)
+	vci, _ := a.IntField("fritzbox_dsl_link", "vci")
+	require.Equal(t, 32, vci)
+}
+
+func TestGatherDSLIntervalStatistics(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetDSLIntervalStatistics = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	intervals := gatheredMetricsByTags(&a, "fritzbox_dsl_statistics", "fritz_dsl_interval")
+	require.Equal(t, 4, len(intervals))
+	for _, interval := range dslStatisticsIntervals {
+		require.Contains(t, intervals, interval[0])
+		statistics := testWANDSLIfConfig1IntervalStatistics[interval[1]]
+		require.Equal(t, statistics[0], intervals[interval[0]].Fields["receive_blocks"], interval[0])
+		require.Equal(t, statistics[1], intervals[interval[0]].Fields["errored_secs"], interval[0])
+		require.Equal(t, statistics[2], intervals[interval[0]].Fields["crc_errors"], interval[0])
+	}
+	require.Equal(t, uint(2000), intervals["last_showtime"].Fields["receive_blocks"])
+	require.Equal(t, uint(1), intervals["quarter_hour"].Fields["crc_errors"])
+}
+
+func TestGatherDSLSpectrum(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetDSLSpectrum = true
+	plugin.DSLSpectrumBands = 8
+	plugin.FullQueryCycle = 1
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	bands := gatheredMetricsByTags(&a, "fritzbox_dsl_spectrum", "fritz_dsl_spectrum_band")
+	require.Equal(t, 8, len(bands))
+	require.Equal(t, 64, bands["001"].Fields["tone_start"])
+	require.Equal(t, 127, bands["001"].Fields["tone_end"])
+	require.Equal(t, 9.5, bands["001"].Fields["bits_avg"])
+	require.Equal(t, 43, bands["001"].Fields["snr_min"])
+	require.Equal(t, 1, testServerHandler.WebLogins)
+
+	// Cached session is re-used
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 8, len(gatheredMetricsByTags(&a, "fritzbox_dsl_spectrum", "fritz_dsl_spectrum_band")))
+	require.Equal(t, 1, testServerHandler.WebLogins)
+
+	// Expired session triggers a new login
+	for _, deviceInfo := range plugin.deviceInfos {
+		deviceInfo.webSession.sid = "0123456789abcdef"
+	}
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 8, len(gatheredMetricsByTags(&a, "fritzbox_dsl_spectrum", "fritz_dsl_spectrum_band")))
+	require.Equal(t, 2, testServerHandler.WebLogins)
+}
+
+func TestGatherWANThroughput(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.True(t, a.HasMeasurement("fritzbox_wan"))
+	require.False(t, a.HasField("fritzbox_wan", "tx_bits_per_second"))
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.True(t, a.HasFloatField("fritzbox_wan", "tx_bits_per_second"))
+	require.True(t, a.HasFloatField("fritzbox_wan", "rx_bits_per_second"))
+	require.True(t, a.HasFloatField("fritzbox_wan", "upstream_utilization"))
+	require.True(t, a.HasFloatField("fritzbox_wan", "downstream_utilization"))
+
+	// A decreasing WAN connection uptime indicates a counter reset (independent of get_device_info)
+	plugin.GetDeviceInfo = false
+	testServerHandler.Restarted = true
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.True(t, a.HasMeasurement("fritzbox_wan"))
+	require.False(t, a.HasField("fritzbox_wan", "tx_bits_per_second"))
+}
+
+func TestGatherLineDSL(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	for _, measurement := range []string{"fritzbox_wan", "fritzbox_dsl", "fritzbox_dsl_link", "fritzbox_ppp", "fritzbox_line"} {
+		require.Equal(t, "dsl", a.TagValue(measurement, "fritz_wan_access_type"), measurement)
+	}
+	require.Equal(t, "WANDSLInterfaceConfig1", a.TagValue("fritzbox_line", "fritz_service"))
+	lines := gatheredMetrics(&a, "fritzbox_line")
+	require.Equal(t, 1, len(lines))
+	require.Equal(t, true, lines[0].Fields["link_up"])
+	require.Equal(t, uint(236716000), lines[0].Fields["downstream_sync_rate"])
+	require.Equal(t, uint(1), lines[0].Fields["resyncs"])
+	require.Equal(t, uint(4), lines[0].Fields["errored_secs"])
+}
+
+func TestGatherLineCable(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true, WANAccessType: "X_AVM-DE_Cable"}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.FullQueryCycle = 2
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, "cable", a.TagValue("fritzbox_wan", "fritz_wan_access_type"))
+	require.Equal(t, "cable", a.TagValue("fritzbox_line", "fritz_wan_access_type"))
+	require.Equal(t, "WANCommonInterfaceConfig1", a.TagValue("fritzbox_line", "fritz_service"))
+	lines := gatheredMetrics(&a, "fritzbox_line")
+	require.Equal(t, 1, len(lines))
+	require.Equal(t, uint(240893000), lines[0].Fields["downstream_sync_rate"])
+	require.Equal(t, uint(86400), lines[0].Fields["connection_uptime"])
+	require.Equal(t, uint(0), lines[0].Fields["connection_resets"])
+	require.False(t, a.HasField("fritzbox_line", "line_uptime"))
+	require.False(t, a.HasField("fritzbox_line", "resyncs"))
+
+	// Like the DSL line stats, the line stats are reported during full queries only
+	testServerHandler.Restarted = true
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.True(t, a.HasMeasurement("fritzbox_wan"))
+	require.False(t, a.HasMeasurement("fritzbox_line"))
+
+	// Decreasing connection uptime indicates a connection reset (even if detected in between full queries)
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	lines = gatheredMetrics(&a, "fritzbox_line")
+	require.Equal(t, 1, len(lines))
+	require.Equal(t, uint(42), lines[0].Fields["connection_uptime"])
+	require.Equal(t, uint(1), lines[0].Fields["connection_resets"])
+}
+
+func TestGatherDOCSISInfo(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true, WANAccessType: "X_AVM-DE_Cable"}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetDOCSISInfo = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	channels := gatheredMetricsByTags(&a, "fritzbox_docsis_channel", "fritz_docsis_direction", "fritz_docsis_version", "fritz_docsis_channel")
+	require.Equal(t, 5, len(channels))
+	require.NotNil(t, channels["downstream:3.0:7"])
+	require.Equal(t, "cable", channels["downstream:3.0:7"].Tags["fritz_wan_access_type"])
+	require.Equal(t, -37.6, channels["downstream:3.0:7"].Fields["mse"])
+	require.NotNil(t, channels["downstream:3.1:33"])
+	require.Equal(t, int64(1234), channels["downstream:3.1:33"].Fields["corrected_errors"])
+	require.NotNil(t, channels["upstream:3.0:2"])
+	require.Equal(t, 44.0, channels["upstream:3.0:2"].Fields["power_level"])
+}
+
+func TestGatherDOCSISInfoNonCable(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetDOCSISInfo = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.False(t, a.HasMeasurement("fritzbox_docsis_channel"))
+	require.Equal(t, 0, testServerHandler.WebLogins)
+}
+
+func TestGatherOnlineMonitor(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetOnlineMonitor = true
+	plugin.FullQueryCycle = 3
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	samples := gatheredMetrics(&a, "fritzbox_online_monitor")
+	require.Equal(t, 5, len(samples))
+	for sampleIndex := 1; sampleIndex < len(samples); sampleIndex++ {
+		require.Equal(t, 5*time.Second, samples[sampleIndex].Time.Sub(samples[sampleIndex-1].Time))
+	}
+	require.Equal(t, "DSL", samples[0].Tags["fritz_online_monitor_group"])
+	require.Equal(t, int64(620000), samples[4].Fields["downstream_bps"])
+	// Timestamps are derived from the device's clock (which is 2 minutes ahead)
+	require.True(t, samples[4].Time.After(time.Now().Add(time.Minute)))
+	latest := samples[4].Time
+
+	// Already reported samples are skipped
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 0, len(gatheredMetrics(&a, "fritzbox_online_monitor")))
+
+	// New samples continue the previous timestamps
+	for _, deviceInfo := range plugin.deviceInfos {
+		state := deviceInfo.onlineMonitorStates["DSL"]
+		state.fetched = state.fetched.Add(-10 * time.Second)
+	}
+	testServerHandler.OnlineMonitor = testOnlineMonitor2
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	samples = gatheredMetrics(&a, "fritzbox_online_monitor")
+	require.Equal(t, 2, len(samples))
+	require.Equal(t, latest.Add(5*time.Second), samples[0].Time)
+	require.Equal(t, int64(130000), samples[0].Fields["downstream_internet_bps"])
+	require.Equal(t, latest.Add(10*time.Second), samples[1].Time)
+	require.Equal(t, int64(140000), samples[1].Fields["downstream_internet_bps"])
+	// The device's clock is only queried during full queries
+	require.Equal(t, 1, testServerHandler.TimeInfos)
+}
+
+func TestGatherAHAInfo(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetAHAInfo = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	devices := gatheredMetricsByTags(&a, "fritzbox_aha_device", "fritz_aha_ain", "fritz_aha_button_ain")
+	require.Equal(t, 8, len(devices))
+	require.Equal(t, "FRITZ!DECT 200", devices["116300123456"].Tags["fritz_aha_product"])
+	require.Equal(t, "group", devices["grp303E4F-3F7D9BE07"].Tags["fritz_aha_type"])
+	require.Equal(t, 2, devices["grp303E4F-3F7D9BE07"].Fields["members"])
+	require.Equal(t, false, devices["116570445566"].Fields["present"])
+	powerMeters := gatheredMetricsByTags(&a, "fritzbox_aha_powermeter", "fritz_aha_ain", "fritz_aha_button_ain")
+	require.Equal(t, 1, len(powerMeters))
+	require.Equal(t, 82.15, powerMeters["116300123456"].Fields["power"])
+	require.Equal(t, 123456, powerMeters["116300123456"].Fields["energy"])
+	temperatures := gatheredMetricsByTags(&a, "fritzbox_aha_temperature", "fritz_aha_ain", "fritz_aha_button_ain")
+	require.Equal(t, 3, len(temperatures))
+	require.Equal(t, 24.5, temperatures["116300123456"].Fields["celsius"])
+	humidities := gatheredMetricsByTags(&a, "fritzbox_aha_humidity", "fritz_aha_ain", "fritz_aha_button_ain")
+	require.Equal(t, 1, len(humidities))
+	require.Equal(t, 43, humidities["130770099887-1"].Fields["rel_humidity"])
+	thermostats := gatheredMetricsByTags(&a, "fritzbox_aha_thermostat", "fritz_aha_ain", "fritz_aha_button_ain")
+	require.Equal(t, 3, len(thermostats))
+	require.Equal(t, 20.5, thermostats["099950012345"].Fields["current"])
+	require.Equal(t, 21.0, thermostats["099950012345"].Fields["target"])
+	require.Equal(t, true, thermostats["099950054321"].Fields["off"])
+	require.Equal(t, true, thermostats["099950054321"].Fields["window_open"])
+	require.NotContains(t, thermostats["099950054321"].Fields, "target")
+	require.Equal(t, 2, len(gatheredMetricsByTags(&a, "fritzbox_aha_button", "fritz_aha_ain", "fritz_aha_button_ain")))
+	lamps := gatheredMetricsByTags(&a, "fritzbox_aha_lamp", "fritz_aha_ain", "fritz_aha_button_ain")
+	require.Equal(t, 1, len(lamps))
+	require.Equal(t, 2700, lamps["113240088776-1"].Fields["color_temperature"])
+	require.Equal(t, 50, lamps["113240088776-1"].Fields["level_percentage"])
+	blinds := gatheredMetricsByTags(&a, "fritzbox_aha_blind", "fritz_aha_ain", "fritz_aha_button_ain")
+	require.Equal(t, 1, len(blinds))
+	require.Equal(t, 10, blinds["142520034567-1"].Fields["level_percentage"])
+	require.Equal(t, 1, len(gatheredMetricsByTags(&a, "fritzbox_aha_alert", "fritz_aha_ain", "fritz_aha_button_ain")))
+	templates := gatheredMetricsByTags(&a, "fritzbox_aha_template", "fritz_aha_ain", "fritz_aha_button_ain")
+	require.Equal(t, 1, len(templates))
+	require.Equal(t, 2, templates["tmp303E4F-3F7D9BE08"].Fields["devices"])
+	require.Equal(t, "homeautoswitch", templates["tmp303E4F-3F7D9BE08"].Tags["fritz_service"])
+	require.False(t, a.HasMeasurement("fritzbox_aha_stats"))
+}
+
+func TestGatherAHAStats(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetAHAInfo = true
+	plugin.GetAHAStats = true
+	plugin.FullQueryCycle = 1
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 42, len(gatheredMetrics(&a, "fritzbox_aha_stats")))
+	// Samples are reported in chronological order; the map keeps the latest one per series
+	latestStats := gatheredMetricsByTags(&a, "fritzbox_aha_stats", "fritz_aha_ain", "fritz_aha_stats_type", "fritz_aha_stats_grid")
+	require.Equal(t, 24.5, latestStats["116300123456:temperature:900"].Fields["value"])
+	energy := latestStats["116300123456:energy:86400"]
+	require.NotNil(t, energy)
+	require.Equal(t, "homeautoswitch", energy.Tags["fritz_service"])
+	require.Equal(t, 512.0, energy.Fields["value"])
+	require.Equal(t, time.Unix(1706788800, 0), energy.Time)
+
+	// Already reported samples are skipped
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.False(t, a.HasMeasurement("fritzbox_aha_stats"))
+}
+
+func TestGatherAHAInfoUnsupported(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true, NoAHA: true}
+	plugin, _ := newTestPlugin(t, testServerHandler)
+	plugin.GetAHAInfo = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.False(t, a.HasMeasurement("fritzbox_aha_device"))
+}
+
+func TestParseDSLDestinationAddress(t *testing.T) {
+	vpi, vci, found := parseDSLDestinationAddress("PVC: 1/32")
+	require.True(t, found)
+	require.Equal(t, 1, vpi)
+	require.Equal(t, 32, vci)
+	vpi, vci, found = parseDSLDestinationAddress("8/35")
+	require.True(t, found)
+	require.Equal(t, 8, vpi)
+	require.Equal(t, 35, vci)
+	_, _, found = parseDSLDestinationAddress("")
+	require.False(t, found)
+}
+
+func TestGetBandFromChannel(t *testing.T) {
+	require.Equal(t, "2G", getBandFromChannel("1"))
+	require.Equal(t, "2G", getBandFromChannel("4"))
+	require.Equal(t, "2G", getBandFromChannel("14"))
+	require.Equal(t, "5G", getBandFromChannel("36"))
+	require.Equal(t, "5G", getBandFromChannel("165"))
+	require.Equal(t, "6G", getBandFromChannel("233"))
+	require.Equal(t, "", getBandFromChannel("0"))
+	require.Equal(t, "", getBandFromChannel("auto"))
+	require.Equal(t, "6G", getBandFromFrequency("6000"))
+	require.Equal(t, "", getBandFromFrequency(""))
+	require.Equal(t, "2G", getNetworkFromChannel("0"))
+	require.Equal(t, "5G", getNetworkFromChannel("auto"))
+}
+
+func TestGetMeshClientFilter(t *testing.T) {
+	plugin := NewFritzBox()
+	plugin.MeshClientMACInclude = []string{"3c:a6:2f:*"}
+	clientFilter, err := plugin.getMeshClientFilter()
+	require.NoError(t, err)
+	require.True(t, clientFilter.macInclude.Match("3C:A6:2F:00:00:01"))
+	require.Nil(t, clientFilter.nameInclude)
+	plugin = NewFritzBox()
+	plugin.MeshClientNameInclude = []string{"laptop*", "/^client[0-9]+$/"}
+	plugin.MeshClientMACExclude = []string{"/^3c:a6:2f:/"}
+	clientFilter, err = plugin.getMeshClientFilter()
+	require.NoError(t, err)
+	require.True(t, clientFilter.nameInclude.Match("laptop1"))
+	require.True(t, clientFilter.nameInclude.Match("client12"))
+	require.False(t, clientFilter.nameInclude.Match("client1a"))
+	require.True(t, clientFilter.macExclude.Match("3C:A6:2F:00:00:01"))
+	require.False(t, clientFilter.macExclude.Match("00:3C:A6:2F:00:01"))
+}
+
+func TestGetMeshClientFilterInvalid(t *testing.T) {
+	invalidPatterns := map[string]func(*FritzBox, []string){
+		"mesh_client_name_include":      func(plugin *FritzBox, patterns []string) { plugin.MeshClientNameInclude = patterns },
+		"mesh_client_name_exclude":      func(plugin *FritzBox, patterns []string) { plugin.MeshClientNameExclude = patterns },
+		"mesh_client_mac_include":       func(plugin *FritzBox, patterns []string) { plugin.MeshClientMACInclude = patterns },
+		"mesh_client_mac_exclude":       func(plugin *FritzBox, patterns []string) { plugin.MeshClientMACExclude = patterns },
+		"mesh_client_interface_include": func(plugin *FritzBox, patterns []string) { plugin.MeshClientInterfaceInclude = patterns },
+		"mesh_client_interface_exclude": func(plugin *FritzBox, patterns []string) { plugin.MeshClientInterfaceExclude = patterns },
+	}
+	for option, setPatterns := range invalidPatterns {
+		for _, pattern := range []string{"[client", "/client(/"} {
+			plugin := NewFritzBox()
+			setPatterns(plugin, []string{pattern})
+			_, err := plugin.getMeshClientFilter()
+			require.ErrorContains(t, err, option, pattern)
+		}
+	}
+}
+
+func TestGatherMeshClientsChained(t *testing.T) {
+	chainedMeshList, err := os.ReadFile(testMeshList4)
+	require.NoError(t, err)
+	testServerHandler := &testServerHandler{Debug: true, MeshList: string(chainedMeshList)}
+	plugin, testServerURL := newTestPlugin(t, testServerHandler)
+	plugin.GetMeshInfo = []string{testServerURL.Hostname()}
+	plugin.GetMeshClients = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	clients := gatheredMetricsByTags(&a, "fritzbox_mesh_client", "fritz_mesh_client_name")
+	require.Equal(t, 1, len(clients))
+	require.Equal(t, "master1>rep-living>rep-attic", clients["client1"].Tags["fritz_mesh_client_path"])
+	require.Equal(t, 3, clients["client1"].Fields["hop_count"])
+	require.Equal(t, 585000, clients["client1"].Fields["bottleneck_data_rate_rx"])
+	require.Equal(t, 650000, clients["client1"].Fields["bottleneck_data_rate_tx"])
+}
+
+func TestGatherMeshClientEvents(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, testServerURL := newTestPlugin(t, testServerHandler)
+	plugin.GetMeshInfo = []string{testServerURL.Hostname()}
+	plugin.GetMeshClientEvents = true
+	plugin.FullQueryCycle = 1
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.False(t, a.HasMeasurement("fritzbox_mesh_event"))
+
+	roamedMeshList := regexp.MustCompile(`"ni-114",(\s*)"node_interface_2_uid": "ni-134"`).ReplaceAllString(testHostsMeshList, `"ni-115",${1}"node_interface_2_uid": "ni-134"`)
+	testServerHandler.MeshList = roamedMeshList
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, "roam", a.TagValue("fritzbox_mesh_event", "fritz_mesh_event"))
+	require.Equal(t, "unknown6", a.TagValue("fritzbox_mesh_event", "fritz_mesh_client_name"))
+	require.Equal(t, "master1:WLAN:AP:2G:0", a.TagValue("fritzbox_mesh_event", "fritz_mesh_client_old_link"))
+	require.Equal(t, "master1:WLAN:AP:5G:0", a.TagValue("fritzbox_mesh_event", "fritz_mesh_client_new_link"))
+
+	// Renaming a client does not affect its state
+	renamedMeshList := strings.ReplaceAll(roamedMeshList, `"device_name": "unknown6"`, `"device_name": "unknown7"`)
+	testServerHandler.MeshList = renamedMeshList
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.False(t, a.HasMeasurement("fritzbox_mesh_event"))
+
+	// A different client using the same name is tracked separately
+	testServerHandler.MeshList = strings.ReplaceAll(renamedMeshList, `"n-133"`, `"n-140"`)
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	events := make(map[string]string)
+	for _, metric := range gatheredMetrics(&a, "fritzbox_mesh_event") {
+		require.Equal(t, "unknown7", metric.Tags["fritz_mesh_client_name"])
+		events[metric.Tags["fritz_mesh_event"]] = metric.Tags["fritz_mesh_client_name"]
+	}
+	require.Equal(t, map[string]string{"disconnect": "unknown7", "connect": "unknown7"}, events)
+}
+
+func TestGatherDiscoverMeshSlaves(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, testServerURL := newTestPlugin(t, testServerHandler)
+	plugin.Devices = [][]string{{"http://localhost:" + testServerURL.Port(), "user", "secret"}}
+	plugin.GetMeshInfo = []string{"localhost"}
+	plugin.DiscoverMeshSlaves = true
+	plugin.FullQueryCycle = 1
+	// Let the configured mesh master resolve to an address different from the slave's one
+	plugin.lookupHost = testLookupHost(map[string][]string{"localhost": {"192.0.2.1"}})
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	discoveredSlave := "http://127.0.0.1:" + testServerURL.Port()
+	require.Equal(t, [][]string{{discoveredSlave, "user", "secret"}}, plugin.getDiscoveredDevices())
+	discoveredDevices := gatheredMetricsByTags(&a, "fritzbox_device", "fritz_device")
+	require.Contains(t, discoveredDevices, "localhost")
+	require.Contains(t, discoveredDevices, "127.0.0.1")
+	require.Contains(t, plugin.deviceInfos, discoveredSlave)
+
+	// The slave left the mesh
+	testServerHandler.MeshList = strings.ReplaceAll(testHostsMeshList, `"mesh_role": "slave"`, `"mesh_role": "unknown"`)
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 0, len(plugin.getDiscoveredDevices()))
+	require.NotContains(t, plugin.deviceInfos, discoveredSlave)
+}
+
+func TestGatherDiscoverMeshSlavesConfigured(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, testServerURL := newTestPlugin(t, testServerHandler)
+	plugin.GetMeshInfo = []string{"localhost"}
+	plugin.DiscoverMeshSlaves = true
+
+	var a testutil.Accumulator
+
+	// The configured host name resolves to the slave's address
+	plugin.Devices = [][]string{{"http://localhost:" + testServerURL.Port(), "user", "secret"}}
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 0, len(plugin.getDiscoveredDevices()))
+
+	// The configured host name matches the slave's name in the host list
+	plugin, _ = newTestPlugin(t, testServerHandler)
+	plugin.GetMeshInfo = []string{"localhost"}
+	plugin.DiscoverMeshSlaves = true
+	plugin.lookupHost = testLookupHost(map[string][]string{"localhost": {"192.0.2.1"}})
+	plugin.Devices = [][]string{{"http://localhost:" + testServerURL.Port(), "user", "secret"}, {"http://SLAVE1:" + testServerURL.Port(), "user", "secret"}}
+	a.ClearMetrics()
+	_ = a.GatherError(plugin.Gather)
+	require.Equal(t, 0, len(plugin.getDiscoveredDevices()))
+}
+
+func testLookupHost(hosts map[string][]string) func(string) ([]string, error) {
+	return func(host string) ([]string, error) {
+		addresses, found := hosts[host]
+		if !found {
+			return nil, fmt.Errorf("unknown host: %s", host)
+		}
+		return addresses, nil
+	}
+}
+
+func TestGatherMeshLinkDegraded(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, testServerURL := newTestPlugin(t, testServerHandler)
+	plugin.GetMeshInfo = []string{testServerURL.Hostname()}
+	plugin.MeshLinkDegradedCycles = 1
+	plugin.FullQueryCycle = 1
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	meshLinks := gatheredMetricsByTags(&a, "fritzbox_mesh", "fritz_mesh_node_link")
+	require.Equal(t, 0.75, meshLinks["slave1:WLAN:UPLINK:5G:0"].Fields["utilization_tx"])
+	require.Equal(t, false, meshLinks["slave1:WLAN:UPLINK:5G:0"].Fields["degraded"])
+
+	testServerHandler.MeshList = strings.ReplaceAll(testHostsMeshList, `"cur_data_rate_rx": 1300000`, `"cur_data_rate_rx": 100000`)
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	meshLinks = gatheredMetricsByTags(&a, "fritzbox_mesh", "fritz_mesh_node_link")
+	require.Equal(t, true, meshLinks["slave1:WLAN:UPLINK:5G:0"].Fields["degraded"])
+	require.Equal(t, 100000, meshLinks["slave1:WLAN:UPLINK:5G:0"].Fields["min_data_rate_rx"])
+	require.Equal(t, 700000.0, meshLinks["slave1:WLAN:UPLINK:5G:0"].Fields["avg_data_rate_rx"])
+	require.Equal(t, false, meshLinks["slave1:WLAN:UPLINK:2G:0"].Fields["degraded"])
+}
+
+func TestGatherMeshLinkDegradedChained(t *testing.T) {
+	chainedMeshList, err := os.ReadFile(testMeshList4)
+	require.NoError(t, err)
+	testServerHandler := &testServerHandler{Debug: true, MeshList: string(chainedMeshList)}
+	plugin, testServerURL := newTestPlugin(t, testServerHandler)
+	plugin.GetMeshInfo = []string{testServerURL.Hostname()}
+	plugin.MeshLinkDegradedCycles = 1
+	plugin.FullQueryCycle = 1
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	meshLinks := gatheredMetricsByTags(&a, "fritzbox_mesh", "fritz_mesh_node_link")
+	require.Equal(t, false, meshLinks["rep-attic:WLAN:UPLINK:5G:0"].Fields["degraded"])
+
+	// Degraded backhaul between the repeaters
+	testServerHandler.MeshList = strings.ReplaceAll(string(chainedMeshList), `"cur_data_rate_rx": 650000`, `"cur_data_rate_rx": 100000`)
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	meshLinks = gatheredMetricsByTags(&a, "fritzbox_mesh", "fritz_mesh_node_link")
+	require.Equal(t, true, meshLinks["rep-attic:WLAN:UPLINK:5G:0"].Fields["degraded"])
+	require.Equal(t, 100000, meshLinks["rep-attic:WLAN:UPLINK:5G:0"].Fields["min_data_rate_rx"])
+	require.Equal(t, false, meshLinks["rep-living:WLAN:UPLINK:5G:0"].Fields["degraded"])
+}
+
+func TestGatherMeshNodes(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, testServerURL := newTestPlugin(t, testServerHandler)
+	plugin.GetMeshInfo = []string{testServerURL.Hostname()}
+	plugin.GetMeshNodes = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	meshNodes := gatheredMetricsByTags(&a, "fritzbox_mesh_node", "fritz_mesh_node_name")
+	require.Equal(t, 2, len(meshNodes))
+	master := meshNodes["master1"]
+	require.NotNil(t, master)
+	require.Equal(t, "master", master.Tags["fritz_mesh_node_role"])
+	require.Equal(t, "", master.Tags["fritz_mesh_node_uplink_type"])
+	require.Equal(t, 1, master.Fields["clients_wlan"])
+	require.Equal(t, 1, master.Fields["clients"])
+	require.Nil(t, master.Fields["update_available"])
+	slave := meshNodes["slave1"]
+	require.NotNil(t, slave)
+	require.Equal(t, "slave", slave.Tags["fritz_mesh_node_role"])
+	require.Equal(t, "WLAN", slave.Tags["fritz_mesh_node_uplink_type"])
+	require.Equal(t, "FRITZ!Repeater 3000", slave.Tags["fritz_mesh_node_model"])
+	require.Equal(t, 0, slave.Fields["clients"])
+	require.Equal(t, true, slave.Fields["update_available"])
+}
+
+func TestGatherMeshNodesChained(t *testing.T) {
+	chainedMeshList, err := os.ReadFile(testMeshList4)
+	require.NoError(t, err)
+	testServerHandler := &testServerHandler{Debug: true, MeshList: string(chainedMeshList)}
+	plugin, testServerURL := newTestPlugin(t, testServerHandler)
+	plugin.GetMeshInfo = []string{testServerURL.Hostname()}
+	plugin.GetMeshNodes = true
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	meshNodes := gatheredMetricsByTags(&a, "fritzbox_mesh_node", "fritz_mesh_node_name")
+	require.Equal(t, 3, len(meshNodes))
+	require.Equal(t, "WLAN", meshNodes["rep-living"].Tags["fritz_mesh_node_uplink_type"])
+	require.Equal(t, 0, meshNodes["rep-living"].Fields["clients"])
+	// Second hop repeater
+	require.Equal(t, "WLAN", meshNodes["rep-attic"].Tags["fritz_mesh_node_uplink_type"])
+	require.Equal(t, 1, meshNodes["rep-attic"].Fields["clients_wlan"])
+	meshLinks := gatheredMetricsByTags(&a, "fritzbox_mesh", "fritz_mesh_node_link")
+	require.Equal(t, 2, len(meshLinks))
+	require.Equal(t, 650000, meshLinks["rep-attic:WLAN:UPLINK:5G:0"].Fields["cur_data_rate_rx"])
+	require.Equal(t, 866000, meshLinks["rep-attic:WLAN:UPLINK:5G:0"].Fields["max_data_rate_rx"])
+}
+
+func TestGatherMeshTopologyFiles(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, testServerURL := newTestPlugin(t, testServerHandler)
+	tempDir := t.TempDir()
+	plugin.GetMeshInfo = []string{testServerURL.Hostname()}
+	plugin.MeshTopologyDOTFile = filepath.Join(tempDir, "mesh.dot")
+	plugin.MeshTopologyJSONFile = filepath.Join(tempDir, "mesh.json")
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	dot, err := os.ReadFile(plugin.MeshTopologyDOTFile)
+	require.NoError(t, err)
+	require.Contains(t, string(dot), `"n-1" -> "n-30"`)
+	json, err := os.ReadFile(plugin.MeshTopologyJSONFile)
+	require.NoError(t, err)
+	require.Contains(t, string(json), `"role": "slave"`)
+}
+
+func TestWriteMeshTopology(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	plugin, testServerURL := newTestPlugin(t, testServerHandler)
+	plugin.GetMeshInfo = []string{testServerURL.Hostname()}
+
+	var out bytes.Buffer
+
+	require.NoError(t, plugin.WriteMeshTopology(&out, "dot"))
+	require.Contains(t, out.String(), `"n-1" -> "n-133"`)
+}
+
+func newTestPlugin(t *testing.T, testServerHandler *testServerHandler) (*FritzBox, *url.URL) {
 	testServer := httptest.NewServer(testServerHandler)
-	defer testServer.Close()
+	t.Cleanup(testServer.Close)
 	testServerURL, err := url.Parse(testServer.URL)
 	require.NoError(t, err)
 	plugin := NewFritzBox()
 	plugin.Devices = [][]string{{testServer.URL, "user", "secret"}}
-	plugin.GetMeshInfo = []string{testServerURL.Hostname()}
-	plugin.GetMeshClients = true
 	plugin.Log = createDummyLogger()
 	plugin.Debug = testServerHandler.Debug
+	return plugin, testServerURL
+}
 
-	var a testutil.Accumulator
+func gatheredMetrics(a *testutil.Accumulator, measurement string) []*testutil.Metric {
+	metrics := make([]*testutil.Metric, 0)
+	for _, metric := range a.Metrics {
+		if metric.Measurement == measurement {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics
+}
 
-	require.NoError(t, a.GatherError(plugin.Gather))
-	require.True(t, a.HasMeasurement("fritzbox_device"))
-	require.True(t, a.HasMeasurement("fritzbox_wlan"))
-	require.True(t, a.HasMeasurement("fritzbox_wan"))
-	require.True(t, a.HasMeasurement("fritzbox_dsl"))
-	require.True(t, a.HasMeasurement("fritzbox_ppp"))
-	require.True(t, a.HasMeasurement("fritzbox_mesh"))
-	require.True(t, a.HasMeasurement("fritzbox_mesh_client"))
+// gatheredMetricsByTags maps the gathered metrics of a measurement by the ':' joined non-empty values of the given tags
+func gatheredMetricsByTags(a *testutil.Accumulator, measurement string, tagKeys ...string) map[string]*testutil.Metric {
+	metrics := make(map[string]*testutil.Metric)
+	for _, metric := range gatheredMetrics(a, measurement) {
+		tagValues := make([]string, 0, len(tagKeys))
+		for _, tagKey := range tagKeys {
+			if metric.Tags[tagKey] != "" {
+				tagValues = append(tagValues, metric.Tags[tagKey])
+			}
+		}
+		metrics[strings.Join(tagValues, ":")] = metric
+	}
+	return metrics
 }
 
 func createDummyLogger() *dummyLogger {
@@ -103,7 +1120,23 @@ func (l *dummyLogger) Info(args ...interface{}) {
 }
 
 type testServerHandler struct {
-	Debug bool
+	Debug            bool
+	MeshList         string
+	WLAN1Enabled     bool
+	WebLogins        int
+	WebLogouts       int
+	WebBlockTime     int
+	WANAccessType    string
+	DeviceLogText    bool
+	Restarted        bool
+	TimeLocation     *time.Location
+	DeviceInfos      int
+	NoAHA            bool
+	OnlineMonitor    string
+	NoWLANNeighbours bool
+	WLAN3Channel     string
+	DeviceLog        string
+	TimeInfos        int
 }
 
 func (tsh *testServerHandler) ServeHTTP(out http.ResponseWriter, request *http.Request) {
@@ -111,6 +1144,16 @@ func (tsh *testServerHandler) ServeHTTP(out http.ResponseWriter, request *http.R
 	if tsh.Debug {
 		log.Printf("test: request URL: %s", requestURL)
 	}
+	if request.URL.Path == "/login_sid.lua" {
+		tsh.serveWebLogin(out, request)
+		return
+	} else if request.URL.Path == "/data.lua" {
+		tsh.serveWebData(out, request)
+		return
+	} else if request.URL.Path == "/webservices/homeautoswitch.lua" {
+		tsh.serveHomeautoSwitch(out, request)
+		return
+	}
 	if request.Method == http.MethodPost && request.Header.Get("Authorization") == "" {
 		out.Header().Add("Www-Authenticate", `Digest realm="HTTPS Access",nonce="30492F0B4025DFF7",algorithm=MD5,qop="auth"`)
 		out.WriteHeader(http.StatusUnauthorized)
@@ -120,24 +1163,40 @@ func (tsh *testServerHandler) ServeHTTP(out http.ResponseWriter, request *http.R
 		tsh.serveTr64descXML(out)
 	} else if requestURL == "/upnp/control/deviceinfo" {
 		tsh.serveDeviceInfo(out, request)
+	} else if requestURL == "/upnp/control/time" {
+		tsh.serveTime(out, request)
+	} else if requestURL == "/upnp/control/userif" {
+		tsh.serveUserInterface(out, request)
 	} else if requestURL == "/upnp/control/wlanconfig1" {
 		tsh.serveWLANConfig1(out, request)
 	} else if requestURL == "/upnp/control/wlanconfig2" {
 		tsh.serveWLANConfig2(out, request)
 	} else if requestURL == "/upnp/control/wlanconfig3" {
 		tsh.serveWLANConfig3(out, request)
+	} else if requestURL == "/upnp/control/lanethernetifcfg" {
+		tsh.serveLANEthernetIfCfg(out, request)
 	} else if requestURL == "/upnp/control/wancommonifconfig1" {
 		tsh.serveWANCommonIfConfig1(out, request)
 	} else if requestURL == "/igdupnp/control/WANCommonIFC1" {
 		tsh.serveWANCommonIFC1(out, request)
 	} else if requestURL == "/upnp/control/wandslifconfig1" {
 		tsh.serveWANDSLIfConfig1(out, request)
+	} else if requestURL == "/upnp/control/wandsllinkconfig1" {
+		tsh.serveWANDSLLinkConfig1(out, request)
 	} else if requestURL == "/upnp/control/wanpppconn1" {
 		tsh.serveWANPPPConn1(out, request)
+	} else if requestURL == "/upnp/control/wanipconnection1" {
+		tsh.serveWANIPConn1(out, request)
 	} else if requestURL == "/upnp/control/hosts" {
 		tsh.serveHosts(out, request)
 	} else if requestURL == "/meshlist.lua?sid=9f46d0308fd4fdd9" {
 		tsh.serveHostsMeshList(out, request)
+	} else if requestURL == "/devicehostlist.lua?sid=9f46d0308fd4fdd9" {
+		tsh.serveHostsHostList(out, request)
+	} else if requestURL == "/devicelog.lua?sid=9f46d0308fd4fdd9" {
+		tsh.serveDeviceLog(out, request)
+	} else if requestURL == "/wlanneighbourlist.lua?sid=9f46d0308fd4fdd9" {
+		tsh.serveWLANNeighbourList(out, request)
 	}
 }
 
@@ -273,13 +1332,6 @@ const testTr64descXML = `
 <SCPDURL>/x_appsetupSCPD.xml</SCPDURL>
 </service>
 <service>
-<serviceType>urn:dslforum-org:service:X_AVM-DE_Homeauto:1</serviceType>
-<serviceId>urn:X_AVM-DE_Homeauto-com:serviceId:X_AVM-DE_Homeauto1</serviceId>
-<controlURL>/upnp/control/x_homeauto</controlURL>
-<eventSubURL>/upnp/control/x_homeauto</eventSubURL>
-<SCPDURL>/x_homeautoSCPD.xml</SCPDURL>
-</service>
-<service>
 <serviceType>urn:dslforum-org:service:X_AVM-DE_Homeplug:1</serviceType>
 <serviceId>urn:X_AVM-DE_Homeplug-com:serviceId:X_AVM-DE_Homeplug1</serviceId>
 <controlURL>/upnp/control/x_homeplug</controlURL>
@@ -431,17 +1483,142 @@ const testDeviceInfoGetInfoResponse = `
 <s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
 <s:Body>
 <u:GetInfoResponse xmlns:u="urn:dslforum-org:service:DeviceInfo:1">
+<NewManufacturerName>AVM</NewManufacturerName>
 <NewModelName>Test Model 1</NewModelName>
-<NewUpTime>751513</NewUpTime>
+<NewDescription>Test Model 1 Release 7.57</NewDescription>
+<NewProductClass>AVMFB</NewProductClass>
+<NewSerialNumber>3431C4123456</NewSerialNumber>
+<NewSoftwareVersion>154.07.57</NewSoftwareVersion>
+<NewHardwareVersion>Test Model 1</NewHardwareVersion>
+<NewSpecVersion>1.0</NewSpecVersion>
+<NewProvisioningCode>000.000.000.000</NewProvisioningCode>
+<NewUpTime>%d</NewUpTime>
+</u:GetInfoResponse>
+</s:Body>
+</s:Envelope>
+`
+
+const testTimeGetInfoResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:GetInfoResponse xmlns:u="urn:dslforum-org:service:Time:1">
+<NewNTPServer1>ntp.example.org</NewNTPServer1>
+<NewNTPServer2></NewNTPServer2>
+<NewCurrentLocalTime>%s</NewCurrentLocalTime>
+<NewLocalTimeZone></NewLocalTimeZone>
+<NewLocalTimeZoneName>CET-1CEST-2,M3.5.0/02:00:00,M10.5.0/03:00:00</NewLocalTimeZoneName>
+<NewDaylightSavingsUsed>1</NewDaylightSavingsUsed>
+<NewDaylightSavingsStart>0001-01-01T00:00:00</NewDaylightSavingsStart>
+<NewDaylightSavingsEnd>0001-01-01T00:00:00</NewDaylightSavingsEnd>
+</u:GetInfoResponse>
+</s:Body>
+</s:Envelope>
+`
+
+func (tsh *testServerHandler) serveTime(out http.ResponseWriter, request *http.Request) {
+	action := tsh.getSoapAction(request, "urn:Time-com:serviceId:Time1")
+	if action == "GetInfo" {
+		// Simulate a device clock running 2 minutes ahead
+		tsh.TimeInfos++
+		now := time.Now()
+		if tsh.TimeLocation != nil {
+			now = now.In(tsh.TimeLocation)
+		}
+		localTime := now.Add(2 * time.Minute).Format(time.RFC3339)
+		tsh.writeXML(out, fmt.Sprintf(testTimeGetInfoResponse, localTime))
+	} else {
+		out.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+const testUserInterfaceGetInfoResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:GetInfoResponse xmlns:u="urn:dslforum-org:service:UserInterface:1">
+<NewUpgradeAvailable>1</NewUpgradeAvailable>
+<NewPasswordRequired>0</NewPasswordRequired>
+<NewPasswordUserSelectable>1</NewPasswordUserSelectable>
+<NewWarrantyDate>0001-01-01T00:00:00</NewWarrantyDate>
+<NewX_AVM-DE_Version>154.07.59</NewX_AVM-DE_Version>
+<NewX_AVM-DE_DownloadURL>http://download.avm.de/firmware/test</NewX_AVM-DE_DownloadURL>
+<NewX_AVM-DE_InfoURL>http://download.avm.de/firmware/test/info.txt</NewX_AVM-DE_InfoURL>
+<NewX_AVM-DE_UpdateState>Stopped</NewX_AVM-DE_UpdateState>
+<NewX_AVM-DE_LaborVersion></NewX_AVM-DE_LaborVersion>
+<NewX_AVM-DE_BuildType>Release</NewX_AVM-DE_BuildType>
 </u:GetInfoResponse>
 </s:Body>
 </s:Envelope>
 `
 
+const testUserInterfaceXAVMDEGetInfoResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:X_AVM-DE_GetInfoResponse xmlns:u="urn:dslforum-org:service:UserInterface:1">
+<NewX_AVM-DE_AutoUpdateMode>check</NewX_AVM-DE_AutoUpdateMode>
+<NewX_AVM-DE_UpdateCheckInterval>7</NewX_AVM-DE_UpdateCheckInterval>
+</u:X_AVM-DE_GetInfoResponse>
+</s:Body>
+</s:Envelope>
+`
+
+func (tsh *testServerHandler) serveUserInterface(out http.ResponseWriter, request *http.Request) {
+	action := tsh.getSoapAction(request, "urn:UserInterface-com:serviceId:UserInterface1")
+	if action == "GetInfo" {
+		tsh.writeXML(out, testUserInterfaceGetInfoResponse)
+	} else if action == "X_AVM-DE_GetInfo" {
+		tsh.writeXML(out, testUserInterfaceXAVMDEGetInfoResponse)
+	} else {
+		out.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+const testDeviceInfoGetDeviceLogPathResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:X_AVM-DE_GetDeviceLogPathResponse xmlns:u="urn:dslforum-org:service:DeviceInfo:1">
+<NewDeviceLogPath>/devicelog.lua?sid=9f46d0308fd4fdd9</NewDeviceLogPath>
+</u:X_AVM-DE_GetDeviceLogPathResponse>
+</s:Body>
+</s:Envelope>
+`
+
+const testDeviceInfoGetDeviceLogResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:GetDeviceLogResponse xmlns:u="urn:dslforum-org:service:DeviceInfo:1">
+<NewDeviceLog>01.02.24 12:05:10 Anmeldung des Benutzers admin an der FRITZ!Box-Benutzeroberfläche von IP-Adresse 192.168.178.20.
+01.02.24 11:58:42 DSL-Synchronisierung besteht (Downstream: 236716 kbit/s, Upstream: 46719 kbit/s).
+01.02.24 11:57:30 DSL antwortet nicht (Keine DSL-Synchronisierung).</NewDeviceLog>
+</u:GetDeviceLogResponse>
+</s:Body>
+</s:Envelope>
+`
+
+func (tsh *testServerHandler) serveDeviceLog(out http.ResponseWriter, request *http.Request) {
+	deviceLogFile := tsh.DeviceLog
+	if deviceLogFile == "" {
+		deviceLogFile = testDeviceLog1
+	}
+	deviceLog, _ := os.ReadFile(deviceLogFile)
+	tsh.writeXML(out, string(deviceLog))
+}
+
 func (tsh *testServerHandler) serveDeviceInfo(out http.ResponseWriter, request *http.Request) {
 	action := tsh.getSoapAction(request, "urn:DeviceInfo-com:serviceId:DeviceInfo1")
 	if action == "GetInfo" {
-		tsh.writeXML(out, testDeviceInfoGetInfoResponse)
+		tsh.DeviceInfos++
+		tsh.writeXML(out, fmt.Sprintf(testDeviceInfoGetInfoResponse, tsh.getUptime(751513)))
+	} else if action == "X_AVM-DE_GetDeviceLogPath" && !tsh.DeviceLogText {
+		tsh.writeXML(out, testDeviceInfoGetDeviceLogPathResponse)
+	} else if action == "GetDeviceLog" {
+		tsh.writeXML(out, testDeviceInfoGetDeviceLogResponse)
+	} else {
+		out.WriteHeader(http.StatusInternalServerError)
 	}
 }
 
@@ -472,9 +1649,15 @@ const testWLANConfig1GetAssociationsResponse = `
 func (tsh *testServerHandler) serveWLANConfig1(out http.ResponseWriter, request *http.Request) {
 	action := tsh.getSoapAction(request, "urn:WLANConfiguration-com:serviceId:WLANConfiguration1")
 	if action == "GetInfo" {
-		tsh.writeXML(out, testWLANConfig1GetInfoResponse)
+		if tsh.WLAN1Enabled {
+			tsh.writeXML(out, strings.ReplaceAll(testWLANConfig1GetInfoResponse, "Disabled", "Up"))
+		} else {
+			tsh.writeXML(out, testWLANConfig1GetInfoResponse)
+		}
 	} else if action == "GetTotalAssociations" {
 		tsh.writeXML(out, testWLANConfig1GetAssociationsResponse)
+	} else {
+		out.WriteHeader(http.StatusInternalServerError)
 	}
 }
 
@@ -484,8 +1667,9 @@ const testWLANConfig2GetInfoResponse = `
 <s:Body>
 <u:GetInfoResponse xmlns:u="urn:dslforum-org:service:WLANConfiguration:3">
 <NewStatus>Up</NewStatus>
-<NewChannel>2</NewChannel>
+<NewChannel>37</NewChannel>
 <NewSSID>TestSSID2</NewSSID>
+<NewStandard>ax</NewStandard>
 </u:GetInfoResponse>
 </s:Body>
 </s:Envelope>
@@ -502,12 +1686,102 @@ const testWLANConfig2GetAssociationsResponse = `
 </s:Envelope>
 `
 
+const testWLANConfig2GetWLANExtInfoResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:X_AVM-DE_GetWLANExtInfoResponse xmlns:u="urn:dslforum-org:service:WLANConfiguration:3">
+<NewX_AVM-DE_APEnabled>1</NewX_AVM-DE_APEnabled>
+<NewX_AVM-DE_APType>guest</NewX_AVM-DE_APType>
+<NewX_AVM-DE_FrequencyBand>6000</NewX_AVM-DE_FrequencyBand>
+<NewX_AVM-DE_ChannelWidth>160</NewX_AVM-DE_ChannelWidth>
+<NewX_AVM-DE_TransmitPower>100</NewX_AVM-DE_TransmitPower>
+</u:X_AVM-DE_GetWLANExtInfoResponse>
+</s:Body>
+</s:Envelope>
+`
+
+const testWLANConfig2GetChannelInfoResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:GetChannelInfoResponse xmlns:u="urn:dslforum-org:service:WLANConfiguration:3">
+<NewChannel>37</NewChannel>
+<NewPossibleChannels>1,5,9,13,17,21,25,29,33,37</NewPossibleChannels>
+<NewX_AVM-DE_AutoChannelEnabled>1</NewX_AVM-DE_AutoChannelEnabled>
+<NewX_AVM-DE_FrequencyBand>6000</NewX_AVM-DE_FrequencyBand>
+</u:GetChannelInfoResponse>
+</s:Body>
+</s:Envelope>
+`
+
+const testWLANConfig2GetStatisticsResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:GetStatisticsResponse xmlns:u="urn:dslforum-org:service:WLANConfiguration:3">
+<NewTotalPacketsSent>1000</NewTotalPacketsSent>
+<NewTotalPacketsReceived>2000</NewTotalPacketsReceived>
+</u:GetStatisticsResponse>
+</s:Body>
+</s:Envelope>
+`
+
+const testWLANConfig2GetPacketStatisticsResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:GetPacketStatisticsResponse xmlns:u="urn:dslforum-org:service:WLANConfiguration:3">
+<NewTotalPacketsSent>1001</NewTotalPacketsSent>
+<NewTotalPacketsReceived>2002</NewTotalPacketsReceived>
+</u:GetPacketStatisticsResponse>
+</s:Body>
+</s:Envelope>
+`
+
+const testWLANConfig2GetNeighbourWLANListResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:X_AVM-DE_GetNeighbourWLANListResponse xmlns:u="urn:dslforum-org:service:WLANConfiguration:3">
+<NewX_AVM-DE_NeighbourWLANListPath>/wlanneighbourlist.lua?sid=9f46d0308fd4fdd9</NewX_AVM-DE_NeighbourWLANListPath>
+</u:X_AVM-DE_GetNeighbourWLANListResponse>
+</s:Body>
+</s:Envelope>
+`
+
+const testWLANConfig2GetNightControlResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:X_AVM-DE_GetNightControlResponse xmlns:u="urn:dslforum-org:service:WLANConfiguration:3">
+<NewNightControl>1</NewNightControl>
+<NewNightTimeControlNoForcedOff>0</NewNightTimeControlNoForcedOff>
+</u:X_AVM-DE_GetNightControlResponse>
+</s:Body>
+</s:Envelope>
+`
+
 func (tsh *testServerHandler) serveWLANConfig2(out http.ResponseWriter, request *http.Request) {
 	action := tsh.getSoapAction(request, "urn:WLANConfiguration-com:serviceId:WLANConfiguration2")
 	if action == "GetInfo" {
 		tsh.writeXML(out, testWLANConfig2GetInfoResponse)
 	} else if action == "GetTotalAssociations" {
 		tsh.writeXML(out, testWLANConfig2GetAssociationsResponse)
+	} else if action == "X_AVM-DE_GetWLANExtInfo" {
+		tsh.writeXML(out, testWLANConfig2GetWLANExtInfoResponse)
+	} else if action == "GetChannelInfo" {
+		tsh.writeXML(out, testWLANConfig2GetChannelInfoResponse)
+	} else if action == "GetStatistics" {
+		tsh.writeXML(out, testWLANConfig2GetStatisticsResponse)
+	} else if action == "GetPacketStatistics" {
+		tsh.writeXML(out, testWLANConfig2GetPacketStatisticsResponse)
+	} else if action == "X_AVM-DE_GetNeighbourWLANList" {
+		tsh.writeXML(out, testWLANConfig2GetNeighbourWLANListResponse)
+	} else if action == "X_AVM-DE_GetNightControl" {
+		tsh.writeXML(out, testWLANConfig2GetNightControlResponse)
+	} else {
+		out.WriteHeader(http.StatusInternalServerError)
 	}
 }
 
@@ -537,10 +1811,52 @@ const testWLANConfig3GetAssociationsResponse = `
 
 func (tsh *testServerHandler) serveWLANConfig3(out http.ResponseWriter, request *http.Request) {
 	action := tsh.getSoapAction(request, "urn:WLANConfiguration-com:serviceId:WLANConfiguration3")
-	if action == "GetInfo" {
+	if action == "GetInfo" && tsh.WLAN3Channel != "" {
+		tsh.writeXML(out, strings.Replace(testWLANConfig3GetInfoResponse, "<NewChannel>3</NewChannel>", "<NewChannel>"+tsh.WLAN3Channel+"</NewChannel>", 1))
+	} else if action == "GetInfo" {
 		tsh.writeXML(out, testWLANConfig3GetInfoResponse)
 	} else if action == "GetTotalAssociations" {
 		tsh.writeXML(out, testWLANConfig3GetAssociationsResponse)
+	} else {
+		out.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+const testLANEthernetIfCfgGetInfoResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:GetInfoResponse xmlns:u="urn:dslforum-org:service:LANEthernetInterfaceConfig:1">
+<NewEnable>1</NewEnable>
+<NewStatus>Up</NewStatus>
+<NewMACAddress>3C:A6:2F:00:00:00</NewMACAddress>
+<NewMaxBitRate>1000</NewMaxBitRate>
+<NewDuplexMode>Full</NewDuplexMode>
+</u:GetInfoResponse>
+</s:Body>
+</s:Envelope>
+`
+
+const testLANEthernetIfCfgGetStatisticsResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:GetStatisticsResponse xmlns:u="urn:dslforum-org:service:LANEthernetInterfaceConfig:1">
+<NewBytesSent>123456789</NewBytesSent>
+<NewBytesReceived>987654321</NewBytesReceived>
+<NewPacketsSent>12345</NewPacketsSent>
+<NewPacketsReceived>54321</NewPacketsReceived>
+</u:GetStatisticsResponse>
+</s:Body>
+</s:Envelope>
+`
+
+func (tsh *testServerHandler) serveLANEthernetIfCfg(out http.ResponseWriter, request *http.Request) {
+	action := tsh.getSoapAction(request, "urn:LANEthernetIfCfg-com:serviceId:LANEthernetInterfaceConfig1")
+	if action == "GetInfo" {
+		tsh.writeXML(out, testLANEthernetIfCfgGetInfoResponse)
+	} else if action == "GetStatistics" {
+		tsh.writeXML(out, testLANEthernetIfCfgGetStatisticsResponse)
 	}
 }
 
@@ -554,6 +1870,7 @@ const testWANCommonIfConfig1GetCommonLinkPropertiesResponse = `
 <NewPhysicalLinkStatus>Up</NewPhysicalLinkStatus>
 <NewX_AVM-DE_DownstreamCurrentMaxSpeed>1711517</NewX_AVM-DE_DownstreamCurrentMaxSpeed>
 <NewX_AVM-DE_UpstreamCurrentMaxSpeed>53711</NewX_AVM-DE_UpstreamCurrentMaxSpeed>
+<NewWANAccessType>%s</NewWANAccessType>
 </u:GetCommonLinkPropertiesResponse>
 </s:Body>
 </s:Envelope>
@@ -562,7 +1879,11 @@ const testWANCommonIfConfig1GetCommonLinkPropertiesResponse = `
 func (tsh *testServerHandler) serveWANCommonIfConfig1(out http.ResponseWriter, request *http.Request) {
 	action := tsh.getSoapAction(request, "urn:WANCIfConfig-com:serviceId:WANCommonInterfaceConfig1")
 	if action == "GetCommonLinkProperties" {
-		tsh.writeXML(out, testWANCommonIfConfig1GetCommonLinkPropertiesResponse)
+		wanAccessType := tsh.WANAccessType
+		if wanAccessType == "" {
+			wanAccessType = "DSL"
+		}
+		tsh.writeXML(out, fmt.Sprintf(testWANCommonIfConfig1GetCommonLinkPropertiesResponse, wanAccessType))
 	}
 }
 
@@ -605,6 +1926,16 @@ const testWANDSLIfConfig1GetInfoResponse = `
 <NewDownstreamAttenuation>140</NewDownstreamAttenuation>
 <NewUpstreamPower>498</NewUpstreamPower>
 <NewDownstreamPower>515</NewDownstreamPower>
+<NewStandardUsed>G.993.2_Annex_B</NewStandardUsed>
+<NewLineEncoding>DMT</NewLineEncoding>
+<NewDataPath>Interleaved</NewDataPath>
+<NewInterleaveDepth>8</NewInterleaveDepth>
+<NewATURVendor>AVM</NewATURVendor>
+<NewATUCVendor>BDCM</NewATUCVendor>
+<NewX_AVM-DE_UpstreamINP>40</NewX_AVM-DE_UpstreamINP>
+<NewX_AVM-DE_DownstreamINP>46</NewX_AVM-DE_DownstreamINP>
+<NewX_AVM-DE_UpstreamDelay>4</NewX_AVM-DE_UpstreamDelay>
+<NewX_AVM-DE_DownstreamDelay>8</NewX_AVM-DE_DownstreamDelay>
 </u:GetInfoResponse>
 </s:Body>
 </s:Envelope>
@@ -633,12 +1964,91 @@ const testWANDSLIfConfig1GetStatisticsTotalResponse = `
 </s:Envelope>
 `
 
+// testWANDSLIfConfig1IntervalStatistics maps the interval statistics actions to distinct receive blocks, errored secs and CRC errors
+var testWANDSLIfConfig1IntervalStatistics = map[string][3]uint{
+	"GetStatisticsShowtime":     {1000, 1, 3},
+	"GetStatisticsLastShowtime": {2000, 2, 5},
+	"GetStatisticsCurrentDay":   {300, 4, 7},
+	"GetStatisticsQuarterHour":  {40, 0, 1},
+}
+
+const testWANDSLIfConfig1GetStatisticsIntervalResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:%[1]sResponse xmlns:u="urn:dslforum-org:service:WANDSLInterfaceConfig:1">
+<NewReceiveBlocks>%[2]d</NewReceiveBlocks>
+<NewTransmitBlocks>500</NewTransmitBlocks>
+<NewCellDelin>0</NewCellDelin>
+<NewLinkRetrain>0</NewLinkRetrain>
+<NewInitErrors>0</NewInitErrors>
+<NewInitTimeouts>0</NewInitTimeouts>
+<NewLossOfFraming>0</NewLossOfFraming>
+<NewErroredSecs>%[3]d</NewErroredSecs>
+<NewSeverelyErroredSecs>0</NewSeverelyErroredSecs>
+<NewFECErrors>0</NewFECErrors>
+<NewATUCFECErrors>0</NewATUCFECErrors>
+<NewHECErrors>0</NewHECErrors>
+<NewATUCHECErrors>0</NewATUCHECErrors>
+<NewCRCErrors>%[4]d</NewCRCErrors>
+<NewATUCCRCErrors>0</NewATUCCRCErrors>
+</u:%[1]sResponse>
+</s:Body>
+</s:Envelope>
+`
+
 func (tsh *testServerHandler) serveWANDSLIfConfig1(out http.ResponseWriter, request *http.Request) {
 	action := tsh.getSoapAction(request, "urn:WANDSLIfConfig-com:serviceId:WANDSLInterfaceConfig1")
 	if action == "GetInfo" {
 		tsh.writeXML(out, testWANDSLIfConfig1GetInfoResponse)
 	} else if action == "GetStatisticsTotal" {
 		tsh.writeXML(out, testWANDSLIfConfig1GetStatisticsTotalResponse)
+	} else if statistics, found := testWANDSLIfConfig1IntervalStatistics[action]; found {
+		tsh.writeXML(out, fmt.Sprintf(testWANDSLIfConfig1GetStatisticsIntervalResponse, action, statistics[0], statistics[1], statistics[2]))
+	} else {
+		out.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+const testWANDSLLinkConfig1GetInfoResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:GetInfoResponse xmlns:u="urn:dslforum-org:service:WANDSLLinkConfig:1">
+<NewEnable>1</NewEnable>
+<NewLinkStatus>Up</NewLinkStatus>
+<NewLinkType>PPPoE</NewLinkType>
+<NewDestinationAddress>PVC: 1/32</NewDestinationAddress>
+<NewATMEncapsulation>LLC</NewATMEncapsulation>
+<NewAutoConfig>1</NewAutoConfig>
+<NewATMQoS>UBR</NewATMQoS>
+<NewATMPeakCellRate>0</NewATMPeakCellRate>
+<NewATMSustainableCellRate>0</NewATMSustainableCellRate>
+</u:GetInfoResponse>
+</s:Body>
+</s:Envelope>
+`
+
+const testWANDSLLinkConfig1GetStatisticsResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:GetStatisticsResponse xmlns:u="urn:dslforum-org:service:WANDSLLinkConfig:1">
+<NewATMTransmittedBlocks>12345</NewATMTransmittedBlocks>
+<NewATMReceivedBlocks>54321</NewATMReceivedBlocks>
+<NewAAL5CRCErrors>1</NewAAL5CRCErrors>
+<NewATMCRCErrors>2</NewATMCRCErrors>
+</u:GetStatisticsResponse>
+</s:Body>
+</s:Envelope>
+`
+
+func (tsh *testServerHandler) serveWANDSLLinkConfig1(out http.ResponseWriter, request *http.Request) {
+	action := tsh.getSoapAction(request, "urn:WANDSLLinkConfig-com:serviceId:WANDSLLinkConfig1")
+	if action == "GetInfo" {
+		tsh.writeXML(out, testWANDSLLinkConfig1GetInfoResponse)
+	} else if action == "GetStatistics" {
+		tsh.writeXML(out, testWANDSLLinkConfig1GetStatisticsResponse)
 	}
 }
 
@@ -648,7 +2058,7 @@ const testWANPPPConn1GetInfoResponse = `
 <s:Body>
 <u:GetInfoResponse xmlns:u="urn:dslforum-org:service:WANPPPConnection:1">
 <NewConnectionStatus>Connected</NewConnectionStatus>
-<NewUptime>755581</NewUptime>
+<NewUptime>%d</NewUptime>
 <NewUpstreamMaxBitRate>45048452</NewUpstreamMaxBitRate>
 <NewDownstreamMaxBitRate>56093007</NewDownstreamMaxBitRate>
 </u:GetInfoResponse>
@@ -659,7 +2069,35 @@ const testWANPPPConn1GetInfoResponse = `
 func (tsh *testServerHandler) serveWANPPPConn1(out http.ResponseWriter, request *http.Request) {
 	action := tsh.getSoapAction(request, "urn:WANPPPConnection-com:serviceId:WANPPPConnection1")
 	if action == "GetInfo" {
-		tsh.writeXML(out, testWANPPPConn1GetInfoResponse)
+		tsh.writeXML(out, fmt.Sprintf(testWANPPPConn1GetInfoResponse, tsh.getUptime(755581)))
+	}
+}
+
+const testWANIPConn1GetInfoResponse = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:GetInfoResponse xmlns:u="urn:dslforum-org:service:WANIPConnection:1">
+<NewEnable>1</NewEnable>
+<NewConnectionStatus>%s</NewConnectionStatus>
+<NewUptime>%d</NewUptime>
+<NewConnectionType>IP_Routed</NewConnectionType>
+</u:GetInfoResponse>
+</s:Body>
+</s:Envelope>
+`
+
+func (tsh *testServerHandler) serveWANIPConn1(out http.ResponseWriter, request *http.Request) {
+	action := tsh.getSoapAction(request, "urn:WANIPConnection-com:serviceId:WANIPConnection1")
+	if action == "GetInfo" {
+		// DSL devices connect via PPP (see serveWANPPPConn1)
+		connectionStatus := "Unconfigured"
+		if tsh.WANAccessType != "" {
+			connectionStatus = "Connected"
+		}
+		tsh.writeXML(out, fmt.Sprintf(testWANIPConn1GetInfoResponse, connectionStatus, tsh.getUptime(86400)))
+	} else {
+		out.WriteHeader(http.StatusInternalServerError)
 	}
 }
 
@@ -674,10 +2112,23 @@ const testHostsGetMeshListPath = `
 </s:Envelope>
 `
 
+const testHostsGetHostListPath = `
+<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<s:Body>
+<u:X_AVM-DE_GetHostListPathResponse xmlns:u="urn:dslforum-org:service:Hosts:1">
+<NewX_AVM-DE_HostListPath>/devicehostlist.lua?sid=9f46d0308fd4fdd9</NewX_AVM-DE_HostListPath>
+</u:X_AVM-DE_GetHostListPathResponse>
+</s:Body>
+</s:Envelope>
+`
+
 func (tsh *testServerHandler) serveHosts(out http.ResponseWriter, request *http.Request) {
 	action := tsh.getSoapAction(request, "urn:LanDeviceHosts-com:serviceId:Hosts1")
 	if action == "X_AVM-DE_GetMeshListPath" {
 		tsh.writeXML(out, testHostsGetMeshListPath)
+	} else if action == "X_AVM-DE_GetHostListPath" {
+		tsh.writeXML(out, testHostsGetHostListPath)
 	}
 }
 
@@ -695,6 +2146,7 @@ const testHostsMeshList = `
 					"uid": "ni-114",
 					"name": "AP:2G:0",
 					"type": "WLAN",
+					"current_channel": 6,
 					"node_links": [
 						{
 							"state": "CONNECTED",
@@ -743,6 +2195,9 @@ const testHostsMeshList = `
 		{
 			"uid": "n-30",
 			"device_name": "slave1",
+			"device_model": "FRITZ!Repeater 3000",
+			"device_firmware_version": "181.07.57",
+			"device_mac_address": "3C:A6:2F:00:00:01",
 			"is_meshed": true,
 			"mesh_role": "slave",
 			"node_interfaces": [
@@ -815,7 +2270,139 @@ const testHostsMeshList = `
 `
 
 func (tsh *testServerHandler) serveHostsMeshList(out http.ResponseWriter, request *http.Request) {
-	tsh.writeJSON(out, testHostsMeshList)
+	if tsh.MeshList != "" {
+		tsh.writeJSON(out, tsh.MeshList)
+	} else {
+		tsh.writeJSON(out, testHostsMeshList)
+	}
+}
+
+const testHostsHostList = `<?xml version="1.0" ?>
+<List>
+<Item>
+<Index>1</Index>
+<IPAddress>127.0.0.1</IPAddress>
+<MACAddress>3C:A6:2F:00:00:01</MACAddress>
+<Active>1</Active>
+<HostName>slave1</HostName>
+<InterfaceType>802.11</InterfaceType>
+<X_AVM-DE_UpdateAvailable>1</X_AVM-DE_UpdateAvailable>
+</Item>
+<Item>
+<Index>2</Index>
+<IPAddress>127.0.0.2</IPAddress>
+<MACAddress>00:11:22:33:44:55</MACAddress>
+<Active>1</Active>
+<HostName>nas1</HostName>
+<InterfaceType>Ethernet</InterfaceType>
+<X_AVM-DE_Port>2</X_AVM-DE_Port>
+<X_AVM-DE_Speed>100</X_AVM-DE_Speed>
+</Item>
+</List>
+`
+
+func (tsh *testServerHandler) serveHostsHostList(out http.ResponseWriter, request *http.Request) {
+	tsh.writeXML(out, testHostsHostList)
+}
+
+const testWLANNeighbourList = `<?xml version="1.0" ?>
+<List>
+<Item>
+<SSID>Neighbour1</SSID>
+<BSSID>00:11:22:00:00:01</BSSID>
+<Channel>37</Channel>
+<RSSI>-62</RSSI>
+</Item>
+<Item>
+<SSID>Neighbour2</SSID>
+<BSSID>00:11:22:00:00:02</BSSID>
+<Channel>36</Channel>
+<RSSI>-75</RSSI>
+</Item>
+</List>
+`
+
+func (tsh *testServerHandler) serveWLANNeighbourList(out http.ResponseWriter, request *http.Request) {
+	if tsh.NoWLANNeighbours {
+		out.WriteHeader(http.StatusNotFound)
+		return
+	}
+	tsh.writeXML(out, testWLANNeighbourList)
+}
+
+const testWebSID = "9f46d0308fd4fdd9"
+const testWebChallenge = "2$10000$5A1711$2000$5A1722"
+
+func (tsh *testServerHandler) serveWebLogin(out http.ResponseWriter, request *http.Request) {
+	sid := webSessionInvalidSID
+	if request.URL.Query().Get("logout") == "1" {
+		tsh.WebLogouts++
+	} else if request.Method == http.MethodPost {
+		_ = request.ParseForm()
+		expectedResponse, _ := calculateChallengeResponse(testWebChallenge, "secret")
+		if request.PostForm.Get("username") == "user" && request.PostForm.Get("response") == expectedResponse {
+			sid = testWebSID
+			tsh.WebLogins++
+		}
+	}
+	tsh.writeXML(out, fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?><SessionInfo><SID>%s</SID><Challenge>%s</Challenge><BlockTime>%d</BlockTime></SessionInfo>`, sid, testWebChallenge, tsh.WebBlockTime))
+}
+
+func (tsh *testServerHandler) serveWebData(out http.ResponseWriter, request *http.Request) {
+	_ = request.ParseForm()
+	if request.PostForm.Get("sid") != testWebSID {
+		out.Header().Add("Content-Type", "text/html")
+		_, _ = out.Write([]byte("<html><body>Login</body></html>"))
+		return
+	}
+	if request.PostForm.Get("page") == "dslSpectrum" {
+		spectrum, _ := os.ReadFile(testDSLSpectrum1)
+		tsh.writeJSON(out, string(spectrum))
+	} else if request.PostForm.Get("page") == "docInfo" {
+		info, _ := os.ReadFile(testDOCSISInfo1)
+		tsh.writeJSON(out, string(info))
+	} else if request.PostForm.Get("page") == "netMoni" {
+		onlineMonitor := tsh.OnlineMonitor
+		if onlineMonitor == "" {
+			onlineMonitor = testOnlineMonitor1
+		}
+		monitor, _ := os.ReadFile(onlineMonitor)
+		tsh.writeJSON(out, string(monitor))
+	} else {
+		out.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (tsh *testServerHandler) serveHomeautoSwitch(out http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	if query.Get("sid") != testWebSID {
+		out.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if tsh.NoAHA {
+		out.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch query.Get("switchcmd") {
+	case "getdevicelistinfos":
+		deviceList, _ := os.ReadFile(testAHADeviceList1)
+		tsh.writeXML(out, string(deviceList))
+	case "gettemplatelistinfos":
+		templateList, _ := os.ReadFile(testAHATemplateList1)
+		tsh.writeXML(out, string(templateList))
+	case "getbasicdevicestats":
+		deviceStats, _ := os.ReadFile(testAHADeviceStats1)
+		tsh.writeXML(out, string(deviceStats))
+	default:
+		out.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (tsh *testServerHandler) getUptime(uptime uint) uint {
+	if tsh.Restarted {
+		return 42
+	}
+	return uptime
 }
 
 func (tsh *testServerHandler) getSoapAction(request *http.Request, uri string) string {
@@ -0,0 +1,95 @@
+// hostlist.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"sort"
+	"strings"
+)
+
+type hostList struct {
+	Items    []hostListItem `xml:"Item"`
+	macTable map[string]*hostListItem
+}
+
+func (hostList *hostList) lookupHost(macAddress string) *hostListItem {
+	if hostList.macTable == nil {
+		hostList.macTable = make(map[string]*hostListItem, 0)
+		for itemIndex, item := range hostList.Items {
+			if item.MACAddress != "" {
+				hostList.macTable[strings.ToUpper(item.MACAddress)] = &hostList.Items[itemIndex]
+			}
+		}
+	}
+	return hostList.macTable[strings.ToUpper(macAddress)]
+}
+
+func (hostList *hostList) lookupIPAddress(macAddresses []string) string {
+	for _, macAddress := range macAddresses {
+		host := hostList.lookupHost(macAddress)
+		if host != nil && host.IPAddress != "" {
+			return host.IPAddress
+		}
+	}
+	return ""
+}
+
+func (hostList *hostList) lookupUpdateAvailable(macAddresses []string) (bool, bool) {
+	for _, macAddress := range macAddresses {
+		host := hostList.lookupHost(macAddress)
+		if host != nil {
+			return host.UpdateAvailable != 0, true
+		}
+	}
+	return false, false
+}
+
+type hostListEthernetPort struct {
+	port  int
+	speed int
+	hosts int
+}
+
+// getEthernetPorts derives the LAN port stats from the active Ethernet hosts. As the device does not
+// report the ports' link speeds directly, a port's speed is the highest link speed (X_AVM-DE_Speed in
+// Mbit/s) reported for the hosts attached to it (0 if none of them reports a speed).
+func (hostList *hostList) getEthernetPorts() []*hostListEthernetPort {
+	portTable := make(map[int]*hostListEthernetPort)
+	for _, item := range hostList.Items {
+		if item.Active == 0 || item.InterfaceType != "Ethernet" || item.Port <= 0 {
+			continue
+		}
+		port := portTable[item.Port]
+		if port == nil {
+			port = &hostListEthernetPort{port: item.Port}
+			portTable[item.Port] = port
+		}
+		if item.Speed > port.speed {
+			port.speed = item.Speed
+		}
+		port.hosts++
+	}
+	ports := make([]*hostListEthernetPort, 0, len(portTable))
+	for _, port := range portTable {
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].port < ports[j].port })
+	return ports
+}
+
+type hostListItem struct {
+	Index           int    `xml:"Index"`
+	IPAddress       string `xml:"IPAddress"`
+	MACAddress      string `xml:"MACAddress"`
+	Active          int    `xml:"Active"`
+	HostName        string `xml:"HostName"`
+	InterfaceType   string `xml:"InterfaceType"`
+	Port            int    `xml:"X_AVM-DE_Port"`
+	Speed           int    `xml:"X_AVM-DE_Speed"`
+	UpdateAvailable int    `xml:"X_AVM-DE_UpdateAvailable"`
+}
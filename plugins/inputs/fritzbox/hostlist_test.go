@@ -0,0 +1,63 @@
+// hostlist_test.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"encoding/xml"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testHostList1 = "testdata/hostlist1.xml"
+
+func TestLookupIPAddress1(t *testing.T) {
+	hostList := loadTestHostList(t, testHostList1)
+	require.Equal(t, 5, len(hostList.Items))
+	require.Equal(t, "192.168.178.2", hostList.lookupIPAddress([]string{"3c:a6:2f:00:00:01"}))
+	require.Equal(t, "192.168.178.20", hostList.lookupIPAddress([]string{"00:00:00:00:00:00", "00:11:22:33:44:55"}))
+	require.Equal(t, "", hostList.lookupIPAddress([]string{"00:11:22:33:44:56"}))
+	require.Equal(t, "", hostList.lookupIPAddress([]string{}))
+}
+
+func TestLookupUpdateAvailable1(t *testing.T) {
+	hostList := loadTestHostList(t, testHostList1)
+	updateAvailable, found := hostList.lookupUpdateAvailable([]string{"3C:A6:2F:00:00:01"})
+	require.True(t, found)
+	require.True(t, updateAvailable)
+	updateAvailable, found = hostList.lookupUpdateAvailable([]string{"00:11:22:33:44:55"})
+	require.True(t, found)
+	require.False(t, updateAvailable)
+	_, found = hostList.lookupUpdateAvailable([]string{"00:00:00:00:00:00"})
+	require.False(t, found)
+}
+
+func TestGetEthernetPorts1(t *testing.T) {
+	hostList := loadTestHostList(t, testHostList1)
+	ports := hostList.getEthernetPorts()
+	require.Equal(t, 2, len(ports))
+	require.Equal(t, 1, ports[0].port)
+	require.Equal(t, 1000, ports[0].speed)
+	require.Equal(t, 2, ports[0].hosts)
+	// No host reports a speed for this port
+	require.Equal(t, 3, ports[1].port)
+	require.Equal(t, 0, ports[1].speed)
+	require.Equal(t, 1, ports[1].hosts)
+}
+
+func loadTestHostList(t *testing.T, filename string) *hostList {
+	hostListBytes, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	var hostList hostList
+
+	err = xml.Unmarshal(hostListBytes, &hostList)
+	require.NoError(t, err)
+	return &hostList
+}
@@ -0,0 +1,95 @@
+// meshlink.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"sort"
+)
+
+type meshLinkHistory struct {
+	samples        [][2]int
+	degradedCycles int
+}
+
+func (history *meshLinkHistory) update(dataRates [2]int, window int, degradedRatio float64) {
+	if len(history.samples) > 0 {
+		median := history.median()
+		if float64(dataRates[0]) < degradedRatio*median[0] || float64(dataRates[1]) < degradedRatio*median[1] {
+			history.degradedCycles++
+		} else {
+			history.degradedCycles = 0
+		}
+	}
+	history.samples = append(history.samples, dataRates)
+	if window < 1 {
+		window = 1
+	}
+	if len(history.samples) > window {
+		history.samples = history.samples[len(history.samples)-window:]
+	}
+}
+
+func (history *meshLinkHistory) isDegraded(degradedCycles int) bool {
+	return history.degradedCycles > 0 && history.degradedCycles >= degradedCycles
+}
+
+func (history *meshLinkHistory) min() [2]int {
+	var min [2]int
+	for sampleIndex, sample := range history.samples {
+		for direction := range sample {
+			if sampleIndex == 0 || sample[direction] < min[direction] {
+				min[direction] = sample[direction]
+			}
+		}
+	}
+	return min
+}
+
+func (history *meshLinkHistory) avg() [2]float64 {
+	var avg [2]float64
+	if len(history.samples) == 0 {
+		return avg
+	}
+	for _, sample := range history.samples {
+		for direction := range sample {
+			avg[direction] += float64(sample[direction])
+		}
+	}
+	for direction := range avg {
+		avg[direction] /= float64(len(history.samples))
+	}
+	return avg
+}
+
+func (history *meshLinkHistory) median() [2]float64 {
+	var median [2]float64
+	if len(history.samples) == 0 {
+		return median
+	}
+	for direction := range median {
+		values := make([]int, 0, len(history.samples))
+		for _, sample := range history.samples {
+			values = append(values, sample[direction])
+		}
+		sort.Ints(values)
+		middle := len(values) / 2
+		if len(values)%2 == 0 {
+			median[direction] = float64(values[middle-1]+values[middle]) / 2
+		} else {
+			median[direction] = float64(values[middle])
+		}
+	}
+	return median
+}
+
+func utilization(cur int, max int) float64 {
+	if max <= 0 {
+		return 0
+	}
+	return float64(cur) / float64(max)
+}
@@ -0,0 +1,39 @@
+// meshlink_test.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeshLinkHistory(t *testing.T) {
+	history := &meshLinkHistory{}
+	history.update([2]int{1000, 800}, 4, 0.5)
+	require.False(t, history.isDegraded(2))
+	history.update([2]int{1200, 800}, 4, 0.5)
+	history.update([2]int{800, 600}, 4, 0.5)
+	require.Equal(t, [2]int{800, 600}, history.min())
+	require.Equal(t, [2]float64{1000, 2200.0 / 3}, history.avg())
+	require.Equal(t, [2]float64{1000, 800}, history.median())
+	require.False(t, history.isDegraded(2))
+	history.update([2]int{400, 800}, 4, 0.5)
+	require.False(t, history.isDegraded(2))
+	history.update([2]int{300, 800}, 4, 0.5)
+	require.True(t, history.isDegraded(2))
+	require.Equal(t, 4, len(history.samples))
+	require.Equal(t, [2]int{300, 600}, history.min())
+	history.update([2]int{1000, 800}, 4, 0.5)
+	require.False(t, history.isDegraded(2))
+}
+
+func TestUtilization(t *testing.T) {
+	require.Equal(t, 0.75, utilization(975000, 1300000))
+	require.Equal(t, 0.0, utilization(1000, 0))
+}
@@ -8,15 +8,30 @@
 package fritzbox
 
 import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
 	"github.com/google/uuid"
+	"github.com/influxdata/telegraf/filter"
 )
 
+const meshListSupportedSchemaMajor = "4"
+
 type meshList struct {
 	SchemaVersion string         `json:"schema_version"`
 	Nodes         []meshListNode `json:"nodes"`
 	nodeTable     map[string]*meshListNode
 }
 
+func (meshList *meshList) getSchemaMajor() string {
+	return strings.SplitN(meshList.SchemaVersion, ".", 2)[0]
+}
+
+func (meshList *meshList) isSupportedSchema() bool {
+	return meshList.getSchemaMajor() == meshListSupportedSchemaMajor
+}
+
 func (meshList *meshList) lookupNode(uid string) *meshListNode {
 	if meshList.nodeTable == nil {
 		meshList.nodeTable = make(map[string]*meshListNode, 0)
@@ -28,11 +43,16 @@ func (meshList *meshList) lookupNode(uid string) *meshListNode {
 }
 
 type meshListNode struct {
-	Uid            string                  `json:"uid"`
-	DeviceName     string                  `json:"device_name"`
-	IsMeshed       bool                    `json:"is_meshed"`
-	MeshRole       string                  `json:"mesh_role"`
-	NodeInterfaces []meshListNodeInterface `json:"node_interfaces"`
+	Uid                   string                  `json:"uid"`
+	DeviceName            string                  `json:"device_name"`
+	DeviceModel           string                  `json:"device_model"`
+	DeviceManufacturer    string                  `json:"device_manufacturer"`
+	DeviceFirmwareVersion string                  `json:"device_firmware_version"`
+	DeviceMacAddress      string                  `json:"device_mac_address"`
+	IsMeshed              bool                    `json:"is_meshed"`
+	MeshRole              string                  `json:"mesh_role"`
+	MeshdVersion          string                  `json:"meshd_version"`
+	NodeInterfaces        []meshListNodeInterface `json:"node_interfaces"`
 }
 
 func (node *meshListNode) hasValidDeviceName() bool {
@@ -43,6 +63,19 @@ func (node *meshListNode) hasValidDeviceName() bool {
 	return err != nil
 }
 
+func (node *meshListNode) getMacAddresses() []string {
+	macAddresses := make([]string, 0)
+	if node.DeviceMacAddress != "" {
+		macAddresses = append(macAddresses, node.DeviceMacAddress)
+	}
+	for _, nodeInterface := range node.NodeInterfaces {
+		if nodeInterface.MacAddress != "" {
+			macAddresses = append(macAddresses, nodeInterface.MacAddress)
+		}
+	}
+	return macAddresses
+}
+
 func (node *meshListNode) isMaster() bool {
 	return node.IsMeshed && node.MeshRole == "master"
 }
@@ -52,14 +85,47 @@ func (node *meshListNode) isSlave() bool {
 }
 
 type meshListNodeInterface struct {
-	Uid       string             `json:"uid"`
-	Name      string             `json:"name"`
-	Type      string             `json:"type"`
-	NodeLinks []meshListNodeLink `json:"node_links"`
+	Uid                string                         `json:"uid"`
+	Name               string                         `json:"name"`
+	Type               string                         `json:"type"`
+	MacAddress         string                         `json:"mac_address"`
+	BlockingState      string                         `json:"blocking_state"`
+	NodeLinks          []meshListNodeLink             `json:"node_links"`
+	SSID               string                         `json:"ssid"`
+	OpMode             string                         `json:"opmode"`
+	Security           string                         `json:"security"`
+	SupportedStreamsTx json.RawMessage                `json:"supported_streams_tx"`
+	SupportedStreamsRx json.RawMessage                `json:"supported_streams_rx"`
+	CurrentChannel     int                            `json:"current_channel"`
+	PhyModes           []string                       `json:"phymodes"`
+	ChannelUtilization int                            `json:"channel_utilization"`
+	ANPI               int                            `json:"anpi"`
+	SteeringEnabled    bool                           `json:"steering_enabled"`
+	Friendly11k        bool                           `json:"11k_friendly"`
+	Friendly11v        bool                           `json:"11v_friendly"`
+	LegacyFriendly     bool                           `json:"legacy_friendly"`
+	RRMCompliant       bool                           `json:"rrm_compliant"`
+	ChannelList        []meshListNodeInterfaceChannel `json:"channel_list"`
+}
+
+func (nodeInterface *meshListNodeInterface) getBand() string {
+	for _, namePart := range strings.Split(nodeInterface.Name, ":") {
+		if namePart == "2G" || namePart == "5G" || namePart == "6G" {
+			return namePart
+		}
+	}
+	return ""
+}
+
+type meshListNodeInterfaceChannel struct {
+	Channel int `json:"channel"`
 }
 
 type meshListNodeLink struct {
+	Uid               string `json:"uid"`
+	Type              string `json:"type"`
 	State             string `json:"state"`
+	LastConnected     int64  `json:"last_connected"`
 	Node1Uid          string `json:"node_1_uid"`
 	Node2Uid          string `json:"node_2_uid"`
 	NodeInterface1Uid string `json:"node_interface_1_uid"`
@@ -68,23 +134,56 @@ type meshListNodeLink struct {
 	MaxDataRateTx     int    `json:"max_data_rate_tx"`
 	CurDataRateRx     int    `json:"cur_data_rate_rx"`
 	CurDataRateTx     int    `json:"cur_data_rate_tx"`
+	CurAvailabilityRx int    `json:"cur_availability_rx"`
+	CurAvailabilityTx int    `json:"cur_availability_tx"`
+	RxRsni            int    `json:"rx_rsni"`
+	TxRsni            int    `json:"tx_rsni"`
+	RxRcpi            int    `json:"rx_rcpi"`
+	TxRcpi            int    `json:"tx_rcpi"`
+	Learned           bool   `json:"learned"`
 }
 
 func (link *meshListNodeLink) isConnected() bool {
 	return link.State == "CONNECTED"
 }
 
+func (link *meshListNodeLink) getDataRates(nodeUid string) [4]int {
+	if nodeUid == link.Node1Uid {
+		return [4]int{link.MaxDataRateRx, link.MaxDataRateTx, link.CurDataRateRx, link.CurDataRateTx}
+	}
+	return [4]int{link.MaxDataRateTx, link.MaxDataRateRx, link.CurDataRateTx, link.CurDataRateRx}
+}
+
 func (link *meshListNodeLink) isConnectedTo(nodeInterface *meshListNodeInterface) bool {
 	return link.isConnected() && (link.NodeInterface1Uid == nodeInterface.Uid || link.NodeInterface2Uid == nodeInterface.Uid)
 }
 
 type meshPath struct {
+	name          string
 	parent        *meshPath
 	node          *meshListNode
 	nodeInterface *meshListNodeInterface
 	nodeLink      *meshListNodeLink
 }
 
+func (path *meshPath) getName() string {
+	if path.name != "" {
+		return path.name
+	}
+	return path.node.DeviceName
+}
+
+// getClientKey gets the key identifying the path's client node across mesh list updates. As
+// the client name is user defined and not necessarily unique, the node's MAC address (or its
+// UID, if no MAC address is known) is used.
+func (path *meshPath) getClientKey() string {
+	macAddresses := path.node.getMacAddresses()
+	if len(macAddresses) > 0 {
+		return macAddresses[0]
+	}
+	return path.node.Uid
+}
+
 func (path *meshPath) getRoot() *meshPath {
 	currentPath := path
 	for {
@@ -96,6 +195,17 @@ func (path *meshPath) getRoot() *meshPath {
 	return currentPath
 }
 
+// getUplinkPeer gets the path element of the nearest mesh node (master or slave) upstream of
+// the path's node. Its link is the path node's uplink (or the first link towards it, if the
+// uplink passes non-mesh nodes like switches).
+func (path *meshPath) getUplinkPeer() *meshPath {
+	uplinkPeer := path.parent
+	for uplinkPeer.parent != nil && !uplinkPeer.node.isMaster() && !uplinkPeer.node.isSlave() {
+		uplinkPeer = uplinkPeer.parent
+	}
+	return uplinkPeer
+}
+
 func (path *meshPath) getPeerNodeUid() string {
 	if path.node.Uid == path.nodeLink.Node1Uid {
 		return path.nodeLink.Node2Uid
@@ -104,10 +214,7 @@ func (path *meshPath) getPeerNodeUid() string {
 }
 
 func (path *meshPath) getDataRates() [4]int {
-	if path.node.Uid == path.nodeLink.Node1Uid {
-		return [4]int{path.nodeLink.MaxDataRateRx, path.nodeLink.MaxDataRateTx, path.nodeLink.CurDataRateRx, path.nodeLink.CurDataRateTx}
-	}
-	return [4]int{path.nodeLink.MaxDataRateTx, path.nodeLink.MaxDataRateRx, path.nodeLink.CurDataRateTx, path.nodeLink.CurDataRateRx}
+	return path.nodeLink.getDataRates(path.node.Uid)
 }
 
 func (path *meshPath) contains(node *meshListNode) bool {
@@ -124,6 +231,29 @@ func (path *meshPath) contains(node *meshListNode) bool {
 	return false
 }
 
+func (meshList *meshList) getSlaveNodes() []*meshListNode {
+	nodes := make([]*meshListNode, 0)
+	for nodeIndex, node := range meshList.Nodes {
+		if node.isSlave() {
+			nodes = append(nodes, &meshList.Nodes[nodeIndex])
+		}
+	}
+	return nodes
+}
+
+func (meshList *meshList) getMeshNodes() []*meshListNode {
+	nodes := make([]*meshListNode, 0)
+	for nodeIndex, node := range meshList.Nodes {
+		if node.isMaster() || node.isSlave() {
+			nodes = append(nodes, &meshList.Nodes[nodeIndex])
+		}
+	}
+	return nodes
+}
+
+// getMasterSlavePaths gets the paths from the mesh masters to all reachable mesh slaves (one path
+// per slave uplink). Slaves connected via other slaves (repeater chains) are reached by continuing
+// the walk from the best uplink path of every slave found.
 func (meshList *meshList) getMasterSlavePaths() []*meshPath {
 	paths := make([]*meshPath, 0)
 	for masterNodeIndex, masterNode := range meshList.Nodes {
@@ -142,6 +272,28 @@ func (meshList *meshList) getMasterSlavePaths() []*meshPath {
 			}
 		}
 	}
+	expandedSlaves := make(map[string]bool)
+	for pathIndex := 0; pathIndex < len(paths); pathIndex++ {
+		slaveNode := paths[pathIndex].node
+		if expandedSlaves[slaveNode.Uid] {
+			continue
+		}
+		expandedSlaves[slaveNode.Uid] = true
+		uplinkPath := meshList.getUplinkPath(slaveNode, paths)
+		for slaveInterfaceIndex, slaveInterface := range slaveNode.NodeInterfaces {
+			for slaveLinkIndex, slaveLink := range slaveInterface.NodeLinks {
+				if slaveLink.isConnected() {
+					path := &meshPath{
+						parent:        uplinkPath.parent,
+						node:          slaveNode,
+						nodeInterface: &slaveNode.NodeInterfaces[slaveInterfaceIndex],
+						nodeLink:      &slaveInterface.NodeLinks[slaveLinkIndex],
+					}
+					paths = meshList.collectMasterSlavePaths(paths, path)
+				}
+			}
+		}
+	}
 	return paths
 }
 
@@ -182,39 +334,218 @@ func (meshList *meshList) collectMasterSlavePaths(paths []*meshPath, path *meshP
 	return updatedPaths
 }
 
-func (meshList *meshList) getClientPaths(clientTypes []string) []*meshPath {
+func (meshList *meshList) getUplinkPath(node *meshListNode, masterSlavePaths []*meshPath) *meshPath {
+	var uplinkPath *meshPath
+	var uplinkDataRates [2]int
+	for _, masterSlavePath := range masterSlavePaths {
+		if masterSlavePath.node.Uid == node.Uid {
+			currentDataRates := masterSlavePath.getUplinkDataRates()
+			if uplinkPath == nil || currentDataRates[0] > uplinkDataRates[0] {
+				uplinkPath = masterSlavePath
+				uplinkDataRates = currentDataRates
+			}
+		}
+	}
+	if uplinkPath == nil && node.isMaster() {
+		uplinkPath = &meshPath{node: node}
+	}
+	return uplinkPath
+}
+
+func countClientsByType(clientPaths []*meshPath) map[string]map[string]int {
+	clientCounts := make(map[string]map[string]int)
+	counted := make(map[string]bool)
+	for _, clientPath := range clientPaths {
+		peerUid := clientPath.getRoot().node.Uid
+		countKey := peerUid + ":" + clientPath.node.Uid + ":" + clientPath.nodeInterface.Type
+		if counted[countKey] {
+			continue
+		}
+		counted[countKey] = true
+		if clientCounts[peerUid] == nil {
+			clientCounts[peerUid] = make(map[string]int)
+		}
+		clientCounts[peerUid][clientPath.nodeInterface.Type]++
+	}
+	return clientCounts
+}
+
+func (path *meshPath) getMeshNodeNames() []string {
+	names := make([]string, 0)
+	for currentPath := path; currentPath != nil; currentPath = currentPath.parent {
+		if currentPath.node.isMaster() || currentPath.node.isSlave() {
+			names = append([]string{currentPath.node.DeviceName}, names...)
+		}
+	}
+	return names
+}
+
+func (path *meshPath) getUplinkDataRates() [2]int {
+	uplinkDataRates := [2]int{0, 0}
+	for currentPath := path; currentPath.parent != nil; currentPath = currentPath.parent {
+		dataRates := currentPath.parent.nodeLink.getDataRates(currentPath.node.Uid)
+		uplinkDataRates[0] = minDataRate(uplinkDataRates[0], dataRates[2])
+		uplinkDataRates[1] = minDataRate(uplinkDataRates[1], dataRates[3])
+	}
+	return uplinkDataRates
+}
+
+// minDataRate determines the minimum of two data rates, treating 0 as unknown
+func minDataRate(dataRate1 int, dataRate2 int) int {
+	if dataRate1 == 0 || (dataRate2 != 0 && dataRate2 < dataRate1) {
+		return dataRate2
+	}
+	return dataRate1
+}
+
+type meshClientFilter struct {
+	clientTypes      []string
+	nameInclude      filter.Filter
+	nameExclude      filter.Filter
+	macInclude       filter.Filter
+	macExclude       filter.Filter
+	interfaceInclude filter.Filter
+	interfaceExclude filter.Filter
+	reportUnnamed    bool
+}
+
+func (clientFilter *meshClientFilter) includeType(clientType string) bool {
+	if len(clientFilter.clientTypes) == 0 {
+		return true
+	}
+	for _, includedType := range clientFilter.clientTypes {
+		if clientType == includedType {
+			return true
+		}
+	}
+	return false
+}
+
+func (clientFilter *meshClientFilter) getClientName(node *meshListNode) string {
+	if node.hasValidDeviceName() {
+		return node.DeviceName
+	}
+	if clientFilter.reportUnnamed {
+		macAddresses := node.getMacAddresses()
+		if len(macAddresses) > 0 {
+			return strings.ToUpper(macAddresses[0])
+		}
+	}
+	return ""
+}
+
+func (clientFilter *meshClientFilter) includeClient(name string, node *meshListNode) bool {
+	if !matchFilters(clientFilter.nameInclude, clientFilter.nameExclude, name) {
+		return false
+	}
+	if clientFilter.macInclude != nil || clientFilter.macExclude != nil {
+		macAddresses := node.getMacAddresses()
+		macIncluded := clientFilter.macInclude == nil
+		for _, macAddress := range macAddresses {
+			normalizedMacAddress := strings.ToUpper(macAddress)
+			if clientFilter.macExclude != nil && clientFilter.macExclude.Match(normalizedMacAddress) {
+				return false
+			}
+			if clientFilter.macInclude != nil && clientFilter.macInclude.Match(normalizedMacAddress) {
+				macIncluded = true
+			}
+		}
+		return macIncluded
+	}
+	return true
+}
+
+func (clientFilter *meshClientFilter) includeInterface(nodeInterface *meshListNodeInterface) bool {
+	return matchFilters(clientFilter.interfaceInclude, clientFilter.interfaceExclude, nodeInterface.Name)
+}
+
+// patternFilter matches strings against glob patterns as well as regular expressions.
+type patternFilter struct {
+	globs   filter.Filter
+	regexps []*regexp.Regexp
+}
+
+func (patternFilter *patternFilter) Match(s string) bool {
+	if patternFilter.globs != nil && patternFilter.globs.Match(s) {
+		return true
+	}
+	for _, compiledRegexp := range patternFilter.regexps {
+		if compiledRegexp.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePatternFilter compiles the given patterns into a filter. Patterns enclosed in slashes
+// (e.g. /^client[0-9]+$/) are regular expressions, all other patterns are glob patterns. If
+// upperCase is set, the filter is applied to upper case strings (and regular expressions match
+// case-insensitive). Like filter.Compile, nil is returned for an empty pattern list.
+func compilePatternFilter(patterns []string, upperCase bool) (filter.Filter, error) {
+	globPatterns := make([]string, 0, len(patterns))
+	regexps := make([]*regexp.Regexp, 0)
+	for _, pattern := range patterns {
+		if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			expr := pattern[1 : len(pattern)-1]
+			if upperCase {
+				expr = "(?i)" + expr
+			}
+			compiledRegexp, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, err
+			}
+			regexps = append(regexps, compiledRegexp)
+		} else if upperCase {
+			globPatterns = append(globPatterns, strings.ToUpper(pattern))
+		} else {
+			globPatterns = append(globPatterns, pattern)
+		}
+	}
+	globs, err := filter.Compile(globPatterns)
+	if err != nil {
+		return nil, err
+	}
+	if len(regexps) == 0 {
+		return globs, nil
+	}
+	return &patternFilter{globs: globs, regexps: regexps}, nil
+}
+
+func matchFilters(include filter.Filter, exclude filter.Filter, s string) bool {
+	return (include == nil || include.Match(s)) && (exclude == nil || !exclude.Match(s))
+}
+
+func (meshList *meshList) getClientPaths(clientFilter *meshClientFilter) []*meshPath {
 	paths := make([]*meshPath, 0)
 	for clientNodeIndex, clientNode := range meshList.Nodes {
-		if !clientNode.IsMeshed && clientNode.hasValidDeviceName() {
-			for clientInterfaceIndex, clientInterface := range clientNode.NodeInterfaces {
-				includeClient := len(clientTypes) == 0
-				for _, clientType := range clientTypes {
-					if clientInterface.Type == clientType {
-						includeClient = true
-						break
-					}
-				}
-
-				if includeClient {
-					for clientLinkIndex, clientLink := range clientInterface.NodeLinks {
-						if clientLink.isConnected() {
-							client := &meshPath{
-								node:          &meshList.Nodes[clientNodeIndex],
-								nodeInterface: &clientNode.NodeInterfaces[clientInterfaceIndex],
-								nodeLink:      &clientInterface.NodeLinks[clientLinkIndex],
-							}
-							peerNode := meshList.lookupNode(client.getPeerNodeUid())
-							if peerNode != nil {
-								for peerInterfaceIndex, peerInterface := range peerNode.NodeInterfaces {
-									if clientLink.isConnectedTo(&peerInterface) {
-										peer := &meshPath{
-											node:          peerNode,
-											nodeInterface: &peerNode.NodeInterfaces[peerInterfaceIndex],
-											nodeLink:      client.nodeLink,
-										}
-										client.parent = peer
-										paths = append(paths, client)
+		if clientNode.IsMeshed {
+			continue
+		}
+		clientName := clientFilter.getClientName(&clientNode)
+		if clientName == "" || !clientFilter.includeClient(clientName, &clientNode) {
+			continue
+		}
+		for clientInterfaceIndex, clientInterface := range clientNode.NodeInterfaces {
+			if clientFilter.includeType(clientInterface.Type) {
+				for clientLinkIndex, clientLink := range clientInterface.NodeLinks {
+					if clientLink.isConnected() {
+						client := &meshPath{
+							name:          clientName,
+							node:          &meshList.Nodes[clientNodeIndex],
+							nodeInterface: &clientNode.NodeInterfaces[clientInterfaceIndex],
+							nodeLink:      &clientInterface.NodeLinks[clientLinkIndex],
+						}
+						peerNode := meshList.lookupNode(client.getPeerNodeUid())
+						if peerNode != nil {
+							for peerInterfaceIndex, peerInterface := range peerNode.NodeInterfaces {
+								if clientLink.isConnectedTo(&peerInterface) && clientFilter.includeInterface(&peerInterface) {
+									peer := &meshPath{
+										node:          peerNode,
+										nodeInterface: &peerNode.NodeInterfaces[peerInterfaceIndex],
+										nodeLink:      client.nodeLink,
 									}
+									client.parent = peer
+									paths = append(paths, client)
 								}
 							}
 						}
@@ -12,11 +12,14 @@ import (
 	"os"
 	"testing"
 
+	"github.com/influxdata/telegraf/filter"
 	"github.com/stretchr/testify/require"
 )
 
 const testMeshList1 = "testdata/meshlist1.json"
 const testMeshList2 = "testdata/meshlist2.json"
+const testMeshList3 = "testdata/meshlist3.json"
+const testMeshList4 = "testdata/meshlist4.json"
 
 func TestGetMasterSlavePaths1(t *testing.T) {
 	meshList := loadTestMeshList(t, testMeshList1)
@@ -32,16 +35,148 @@ func TestGetMasterSlavePaths2(t *testing.T) {
 	require.Equal(t, [4]int{1000004, 1000003, 1000002, 1000001}, masterSlavePaths[0].getRoot().getDataRates())
 	require.Equal(t, [4]int{1000004, 1000003, 1000002, 1000001}, masterSlavePaths[1].getRoot().getDataRates())
 }
+func TestGetMasterSlavePathsChained(t *testing.T) {
+	meshList := loadTestMeshList(t, testMeshList4)
+	masterSlavePaths := meshList.getMasterSlavePaths()
+	require.Equal(t, 2, len(masterSlavePaths))
+	require.Equal(t, "rep-living", masterSlavePaths[0].node.DeviceName)
+	require.Equal(t, "rep-attic", masterSlavePaths[1].node.DeviceName)
+	require.Equal(t, "UPLINK:5G:0", masterSlavePaths[1].nodeInterface.Name)
+	require.Equal(t, [4]int{866000, 866000, 650000, 585000}, masterSlavePaths[1].getUplinkPeer().getDataRates())
+}
 func TestGetClientPaths1(t *testing.T) {
 	meshList := loadTestMeshList(t, testMeshList1)
-	clientPaths := meshList.getClientPaths([]string{})
+	clientPaths := meshList.getClientPaths(&meshClientFilter{})
 	require.Equal(t, 20, len(clientPaths))
 }
 func TestGetClientPaths2(t *testing.T) {
 	meshList := loadTestMeshList(t, testMeshList2)
-	clientPaths := meshList.getClientPaths([]string{})
+	clientPaths := meshList.getClientPaths(&meshClientFilter{})
 	require.Equal(t, 12, len(clientPaths))
 }
+func TestGetClientPathsFiltered1(t *testing.T) {
+	meshList := loadTestMeshList(t, testMeshList1)
+	require.Equal(t, 8, len(meshList.getClientPaths(&meshClientFilter{
+		nameInclude: filter.MustCompile([]string{"unknown1*"}),
+		nameExclude: filter.MustCompile([]string{"unknown12"}),
+	})))
+	require.Equal(t, 7, len(meshList.getClientPaths(&meshClientFilter{
+		interfaceInclude: filter.MustCompile([]string{"AP:5G:*"}),
+	})))
+	require.Equal(t, 9, len(meshList.getClientPaths(&meshClientFilter{
+		interfaceExclude: filter.MustCompile([]string{"AP:*"}),
+	})))
+}
+func TestGetClientPathsUnnamed(t *testing.T) {
+	meshList := loadTestMeshList(t, testMeshList1)
+	meshList.Nodes[1].DeviceName = "3f2504e0-4f89-11d3-9a0c-0305e82c3301"
+	meshList.Nodes[1].DeviceMacAddress = "00:11:22:33:44:55"
+	meshList.Nodes[2].DeviceName = ""
+	require.Equal(t, 18, len(meshList.getClientPaths(&meshClientFilter{})))
+	unnamedClientPaths := meshList.getClientPaths(&meshClientFilter{reportUnnamed: true})
+	require.Equal(t, 19, len(unnamedClientPaths))
+	require.Equal(t, "00:11:22:33:44:55", unnamedClientPaths[0].getName())
+	require.Equal(t, 1, len(meshList.getClientPaths(&meshClientFilter{
+		reportUnnamed: true,
+		macInclude:    filter.MustCompile([]string{"00:11:22:*"}),
+	})))
+	require.Equal(t, 18, len(meshList.getClientPaths(&meshClientFilter{
+		reportUnnamed: true,
+		macExclude:    filter.MustCompile([]string{"00:11:22:33:44:55"}),
+	})))
+}
+func TestGetUplinkPath1(t *testing.T) {
+	meshList := loadTestMeshList(t, testMeshList1)
+	masterSlavePaths := meshList.getMasterSlavePaths()
+	masterUplinkPath := meshList.getUplinkPath(meshList.lookupNode("n-1"), masterSlavePaths)
+	require.NotNil(t, masterUplinkPath)
+	require.Equal(t, []string{"master1"}, masterUplinkPath.getMeshNodeNames())
+	require.Equal(t, [2]int{0, 0}, masterUplinkPath.getUplinkDataRates())
+	slaveUplinkPath := meshList.getUplinkPath(meshList.lookupNode("n-30"), masterSlavePaths)
+	require.NotNil(t, slaveUplinkPath)
+	require.Equal(t, []string{"master1", "slave1"}, slaveUplinkPath.getMeshNodeNames())
+	require.Equal(t, [2]int{975000, 1300000}, slaveUplinkPath.getUplinkDataRates())
+	require.Nil(t, meshList.getUplinkPath(meshList.lookupNode("n-17"), masterSlavePaths))
+}
+func TestGetUplinkPath2(t *testing.T) {
+	meshList := loadTestMeshList(t, testMeshList2)
+	masterSlavePaths := meshList.getMasterSlavePaths()
+	slaveUplinkPath := meshList.getUplinkPath(meshList.lookupNode("n-25"), masterSlavePaths)
+	require.NotNil(t, slaveUplinkPath)
+	require.Equal(t, []string{"master1", "slave2"}, slaveUplinkPath.getMeshNodeNames())
+	require.Equal(t, [2]int{1000001, 1000002}, slaveUplinkPath.getUplinkDataRates())
+}
+func TestGetUplinkPathChained(t *testing.T) {
+	meshList := loadTestMeshList(t, testMeshList4)
+	masterSlavePaths := meshList.getMasterSlavePaths()
+	clientPaths := meshList.getClientPaths(&meshClientFilter{})
+	require.Equal(t, 1, len(clientPaths))
+	uplinkPath := meshList.getUplinkPath(clientPaths[0].getRoot().node, masterSlavePaths)
+	require.NotNil(t, uplinkPath)
+	require.Equal(t, []string{"master1", "rep-living", "rep-attic"}, uplinkPath.getMeshNodeNames())
+	require.Equal(t, "WLAN", uplinkPath.nodeInterface.Type)
+	// The rep-living > rep-attic backhaul is the bottleneck
+	require.Equal(t, [2]int{585000, 650000}, uplinkPath.getUplinkDataRates())
+}
+func TestCountClientsByType1(t *testing.T) {
+	meshList := loadTestMeshList(t, testMeshList3)
+	meshNodes := meshList.getMeshNodes()
+	require.Equal(t, 2, len(meshNodes))
+	require.Equal(t, "master1", meshNodes[0].DeviceName)
+	require.Equal(t, "slave1", meshNodes[1].DeviceName)
+	clientCounts := countClientsByType(meshList.getClientPaths(&meshClientFilter{reportUnnamed: true}))
+	require.Equal(t, map[string]map[string]int{"n-1": {"WLAN": 1}}, clientCounts)
+}
+func TestMinDataRate(t *testing.T) {
+	require.Equal(t, 1, minDataRate(1, 2))
+	require.Equal(t, 1, minDataRate(2, 1))
+	require.Equal(t, 2, minDataRate(0, 2))
+	require.Equal(t, 2, minDataRate(2, 0))
+	require.Equal(t, 0, minDataRate(0, 0))
+}
+func TestMeshListSchema(t *testing.T) {
+	meshList1 := loadTestMeshList(t, testMeshList1)
+	require.Equal(t, "4", meshList1.getSchemaMajor())
+	require.True(t, meshList1.isSupportedSchema())
+	meshList3 := loadTestMeshList(t, testMeshList3)
+	require.Equal(t, "4.13", meshList3.SchemaVersion)
+	require.True(t, meshList3.isSupportedSchema())
+	unsupportedMeshList := &meshList{SchemaVersion: "5.0"}
+	require.Equal(t, "5", unsupportedMeshList.getSchemaMajor())
+	require.False(t, unsupportedMeshList.isSupportedSchema())
+}
+func TestMeshListFields3(t *testing.T) {
+	meshList := loadTestMeshList(t, testMeshList3)
+	master := meshList.lookupNode("n-1")
+	require.NotNil(t, master)
+	require.Equal(t, "FRITZ!Box 7590", master.DeviceModel)
+	require.Equal(t, "154.07.57", master.DeviceFirmwareVersion)
+	require.Equal(t, "3C:A6:2F:00:00:00", master.DeviceMacAddress)
+	apInterface := master.NodeInterfaces[1]
+	require.Equal(t, "mesh", apInterface.SSID)
+	require.Equal(t, "AP", apInterface.OpMode)
+	require.Equal(t, "WPA2WPA3", apInterface.Security)
+	require.Equal(t, 36, apInterface.CurrentChannel)
+	require.Equal(t, []string{"a", "n", "ac", "ax"}, apInterface.PhyModes)
+	require.Equal(t, -92, apInterface.ANPI)
+	require.True(t, apInterface.Friendly11k)
+	require.Equal(t, 4, len(apInterface.ChannelList))
+	require.Equal(t, "5G", apInterface.getBand())
+	require.Equal(t, "2G", master.NodeInterfaces[2].getBand())
+	require.Equal(t, "", master.NodeInterfaces[0].getBand())
+	link := apInterface.NodeLinks[0]
+	require.Equal(t, "nl-100", link.Uid)
+	require.Equal(t, "WLAN", link.Type)
+	require.True(t, link.Learned)
+	require.Equal(t, int64(1700000000), link.LastConnected)
+	require.Equal(t, -47, link.RxRcpi)
+	masterSlavePaths := meshList.getMasterSlavePaths()
+	require.Equal(t, 1, len(masterSlavePaths))
+	require.Equal(t, "FRITZ!Repeater 3000", masterSlavePaths[0].node.DeviceModel)
+	clientPaths := meshList.getClientPaths(&meshClientFilter{})
+	require.Equal(t, 1, len(clientPaths))
+	require.Equal(t, "2G", clientPaths[0].getRoot().nodeInterface.getBand())
+}
 
 func loadTestMeshList(t *testing.T, filename string) *meshList {
 	meshListBytes, err := os.ReadFile(filename)
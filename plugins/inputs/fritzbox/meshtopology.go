@@ -0,0 +1,160 @@
+// meshtopology.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type meshTopology struct {
+	Device        string             `json:"device"`
+	SchemaVersion string             `json:"schema_version"`
+	Nodes         []meshTopologyNode `json:"nodes"`
+	Edges         []meshTopologyEdge `json:"edges"`
+	nodeTable     map[string]bool
+	edgeTable     map[string]bool
+}
+
+type meshTopologyNode struct {
+	Uid  string `json:"uid"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type meshTopologyEdge struct {
+	From          string `json:"from"`
+	To            string `json:"to"`
+	InterfaceType string `json:"interface_type"`
+	InterfaceName string `json:"interface_name"`
+	MaxDataRateRx int    `json:"max_data_rate_rx"`
+	MaxDataRateTx int    `json:"max_data_rate_tx"`
+	CurDataRateRx int    `json:"cur_data_rate_rx"`
+	CurDataRateTx int    `json:"cur_data_rate_tx"`
+}
+
+func newMeshTopology(device string, meshList *meshList, clientFilter *meshClientFilter) *meshTopology {
+	topology := &meshTopology{
+		Device:        device,
+		SchemaVersion: meshList.SchemaVersion,
+		Nodes:         make([]meshTopologyNode, 0),
+		Edges:         make([]meshTopologyEdge, 0),
+		nodeTable:     make(map[string]bool),
+		edgeTable:     make(map[string]bool),
+	}
+	for _, masterSlavePath := range meshList.getMasterSlavePaths() {
+		topology.addPath(masterSlavePath)
+	}
+	for _, clientPath := range meshList.getClientPaths(clientFilter) {
+		topology.addPath(clientPath)
+	}
+	return topology
+}
+
+func (topology *meshTopology) addPath(path *meshPath) {
+	if path.parent != nil {
+		topology.addPath(path.parent)
+	}
+	topology.addNode(path)
+	if path.parent != nil {
+		topology.addEdge(path.parent, path)
+	}
+}
+
+func (topology *meshTopology) addNode(path *meshPath) {
+	node := path.node
+	if topology.nodeTable[node.Uid] {
+		return
+	}
+	topology.nodeTable[node.Uid] = true
+	role := "client"
+	if node.isMaster() || node.isSlave() {
+		role = node.MeshRole
+	}
+	topology.Nodes = append(topology.Nodes, meshTopologyNode{
+		Uid:  node.Uid,
+		Name: path.getName(),
+		Role: role,
+	})
+}
+
+func (topology *meshTopology) addEdge(upstream *meshPath, downstream *meshPath) {
+	edgeKey := upstream.nodeLink.NodeInterface1Uid + ":" + upstream.nodeLink.NodeInterface2Uid
+	if upstream.nodeLink.NodeInterface2Uid < upstream.nodeLink.NodeInterface1Uid {
+		edgeKey = upstream.nodeLink.NodeInterface2Uid + ":" + upstream.nodeLink.NodeInterface1Uid
+	}
+	if topology.edgeTable[edgeKey] {
+		return
+	}
+	topology.edgeTable[edgeKey] = true
+	dataRates := upstream.nodeLink.getDataRates(downstream.node.Uid)
+	topology.Edges = append(topology.Edges, meshTopologyEdge{
+		From:          upstream.node.Uid,
+		To:            downstream.node.Uid,
+		InterfaceType: upstream.nodeInterface.Type,
+		InterfaceName: upstream.nodeInterface.Name,
+		MaxDataRateRx: dataRates[0],
+		MaxDataRateTx: dataRates[1],
+		CurDataRateRx: dataRates[2],
+		CurDataRateTx: dataRates[3],
+	})
+}
+
+func writeMeshTopologies(out io.Writer, topologies []*meshTopology, format string) error {
+	switch format {
+	case "dot":
+		for _, topology := range topologies {
+			err := topology.writeDOT(out)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(topologies)
+	}
+	return fmt.Errorf("unsupported mesh topology format: %s", format)
+}
+
+func (topology *meshTopology) writeDOT(out io.Writer) error {
+	_, err := fmt.Fprintf(out, "digraph %s {\n", dotQuote(topology.Device))
+	if err != nil {
+		return err
+	}
+	for _, node := range topology.Nodes {
+		name := node.Name
+		if name == "" {
+			name = node.Uid
+		}
+		shape := "ellipse"
+		if node.Role != "client" {
+			shape = "box"
+		}
+		_, err = fmt.Fprintf(out, "  %s [label=%s, shape=%s];\n", dotQuote(node.Uid), dotQuote(name+"\n"+node.Role), shape)
+		if err != nil {
+			return err
+		}
+	}
+	for _, edge := range topology.Edges {
+		label := fmt.Sprintf("%s %s\nrx %d/%d\ntx %d/%d", edge.InterfaceType, edge.InterfaceName, edge.CurDataRateRx, edge.MaxDataRateRx, edge.CurDataRateTx, edge.MaxDataRateTx)
+		_, err = fmt.Fprintf(out, "  %s -> %s [label=%s];\n", dotQuote(edge.From), dotQuote(edge.To), dotQuote(label))
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(out, "}")
+	return err
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
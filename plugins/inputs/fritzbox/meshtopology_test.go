@@ -0,0 +1,77 @@
+// meshtopology_test.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMeshTopology1(t *testing.T) {
+	meshList := loadTestMeshList(t, testMeshList1)
+	topology := newMeshTopology("fritz.box", meshList, &meshClientFilter{})
+	require.Equal(t, 22, len(topology.Nodes))
+	require.Equal(t, 22, len(topology.Edges))
+	require.Equal(t, meshTopologyNode{Uid: "n-1", Name: "master1", Role: "master"}, topology.Nodes[0])
+	require.Equal(t, meshTopologyNode{Uid: "n-30", Name: "slave1", Role: "slave"}, topology.Nodes[1])
+	require.Equal(t, meshTopologyEdge{
+		From:          "n-1",
+		To:            "n-30",
+		InterfaceType: "WLAN",
+		InterfaceName: "AP:5G:0",
+		MaxDataRateRx: 1300000,
+		MaxDataRateTx: 1300000,
+		CurDataRateRx: 975000,
+		CurDataRateTx: 1300000,
+	}, topology.Edges[1])
+}
+
+func TestNewMeshTopology2(t *testing.T) {
+	meshList := loadTestMeshList(t, testMeshList2)
+	topology := newMeshTopology("fritz.box", meshList, &meshClientFilter{})
+	require.Equal(t, 10, len(topology.Nodes))
+	require.Equal(t, 10, len(topology.Edges))
+	require.Equal(t, "n-125", topology.Edges[1].From)
+	require.Equal(t, "n-25", topology.Edges[1].To)
+}
+
+func TestNewMeshTopologyChained(t *testing.T) {
+	meshList := loadTestMeshList(t, testMeshList4)
+	topologies := []*meshTopology{newMeshTopology("fritz.box", meshList, &meshClientFilter{})}
+	require.Equal(t, 4, len(topologies[0].Nodes))
+	require.Equal(t, 3, len(topologies[0].Edges))
+	var dot bytes.Buffer
+	require.NoError(t, writeMeshTopologies(&dot, topologies, "dot"))
+	require.Contains(t, dot.String(), `"n-1" -> "n-2" [label="WLAN AP:5G:0\nrx 1170000/1733000\ntx 1300000/1733000"];`)
+	// Backhaul between the repeaters
+	require.Contains(t, dot.String(), `"n-2" -> "n-3" [label="WLAN AP:5G:0\nrx 585000/866000\ntx 650000/866000"];`)
+	require.Contains(t, dot.String(), `"n-3" -> "n-4" [label="WLAN AP:2G:0`)
+}
+
+func TestWriteMeshTopologies(t *testing.T) {
+	meshList := loadTestMeshList(t, testMeshList1)
+	topologies := []*meshTopology{newMeshTopology("fritz.box", meshList, &meshClientFilter{clientTypes: []string{"WLAN"}})}
+	var dot bytes.Buffer
+	require.NoError(t, writeMeshTopologies(&dot, topologies, "dot"))
+	require.True(t, strings.HasPrefix(dot.String(), "digraph \"fritz.box\" {\n"))
+	require.Contains(t, dot.String(), `"n-1" [label="master1\nmaster", shape=box];`)
+	require.Contains(t, dot.String(), `"n-1" -> "n-30" [label="WLAN AP:5G:0\nrx 975000/1300000\ntx 1300000/1300000"];`)
+	var jsonOut bytes.Buffer
+	require.NoError(t, writeMeshTopologies(&jsonOut, topologies, "json"))
+	var decoded []meshTopology
+	require.NoError(t, json.Unmarshal(jsonOut.Bytes(), &decoded))
+	require.Equal(t, 1, len(decoded))
+	require.Equal(t, "4.11", decoded[0].SchemaVersion)
+	require.Equal(t, topologies[0].Nodes, decoded[0].Nodes)
+	require.Equal(t, topologies[0].Edges, decoded[0].Edges)
+	require.Error(t, writeMeshTopologies(&jsonOut, topologies, "svg"))
+}
@@ -0,0 +1,151 @@
+// onlinemonitor.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"time"
+)
+
+type onlineMonitor struct {
+	SID  string `json:"sid"`
+	Data struct {
+		SyncGroups []onlineMonitorSyncGroup `json:"sync_groups"`
+	} `json:"data"`
+}
+
+type onlineMonitorSyncGroup struct {
+	Name                string  `json:"name"`
+	SamplingInterval    int     `json:"sampling_interval"`
+	DownstreamMax       int64   `json:"ds_bps_max"`
+	UpstreamMax         int64   `json:"us_bps_max"`
+	DownstreamInternet  []int64 `json:"ds_bps_curr"`
+	DownstreamMulticast []int64 `json:"ds_mc_bps_curr"`
+	DownstreamIPTV      []int64 `json:"ds_iptv_bps_curr"`
+	UpstreamRealtime    []int64 `json:"us_realtime_bps_curr"`
+	UpstreamHigh        []int64 `json:"us_important_bps_curr"`
+	UpstreamDefault     []int64 `json:"us_default_bps_curr"`
+	UpstreamLow         []int64 `json:"us_background_bps_curr"`
+}
+
+// onlineMonitorState keeps the sample buffer of a sync group as fetched by the previous query
+type onlineMonitorState struct {
+	syncGroup *onlineMonitorSyncGroup
+	latest    time.Time
+	fetched   time.Time
+}
+
+type onlineMonitorSample struct {
+	timestamp time.Time
+	fields    map[string]interface{}
+}
+
+const onlineMonitorDefaultSamplingInterval = 5000
+
+func (syncGroup *onlineMonitorSyncGroup) getSamplingInterval() time.Duration {
+	samplingInterval := syncGroup.SamplingInterval
+	if samplingInterval <= 0 {
+		samplingInterval = onlineMonitorDefaultSamplingInterval
+	}
+	return time.Duration(samplingInterval) * time.Millisecond
+}
+
+type onlineMonitorQueue struct {
+	key      string
+	upstream bool
+	values   []int64
+}
+
+func (syncGroup *onlineMonitorSyncGroup) getQueues() []onlineMonitorQueue {
+	return []onlineMonitorQueue{
+		{"downstream_internet_bps", false, syncGroup.DownstreamInternet},
+		{"downstream_multicast_bps", false, syncGroup.DownstreamMulticast},
+		{"downstream_iptv_bps", false, syncGroup.DownstreamIPTV},
+		{"upstream_realtime_bps", true, syncGroup.UpstreamRealtime},
+		{"upstream_high_bps", true, syncGroup.UpstreamHigh},
+		{"upstream_default_bps", true, syncGroup.UpstreamDefault},
+		{"upstream_low_bps", true, syncGroup.UpstreamLow},
+	}
+}
+
+func (syncGroup *onlineMonitorSyncGroup) getSampleCount() int {
+	sampleCount := 0
+	for _, queue := range syncGroup.getQueues() {
+		sampleCount = max(sampleCount, len(queue.values))
+	}
+	return sampleCount
+}
+
+// getNewSampleCount determines the number of samples added since the given previous sample buffer
+// has been fetched. As the device does not report any sample timestamps, this is done by matching
+// the overlapping parts of both buffers. The match is only checked for the expected number of new
+// samples (as derived from the elapsed time) and its direct neighbours, starting with the expected
+// one (which resolves ambiguous matches like constant traffic). If none of them matches (e.g. because
+// the buffers do not overlap at all), false is returned.
+func (syncGroup *onlineMonitorSyncGroup) getNewSampleCount(previous *onlineMonitorSyncGroup, expected int) (int, bool) {
+	if syncGroup.getSamplingInterval() != previous.getSamplingInterval() {
+		return 0, false
+	}
+	sampleCount := syncGroup.getSampleCount()
+	previousQueues := previous.getQueues()
+	for _, candidate := range []int{expected, expected - 1, expected + 1} {
+		if candidate < 0 || sampleCount <= candidate {
+			continue
+		}
+		if syncGroup.isShiftedBy(previousQueues, candidate) {
+			return candidate, true
+		}
+	}
+	return 0, false
+}
+
+func (syncGroup *onlineMonitorSyncGroup) isShiftedBy(previousQueues []onlineMonitorQueue, shift int) bool {
+	for queueIndex, queue := range syncGroup.getQueues() {
+		previousValues := previousQueues[queueIndex].values
+		for valueIndex := shift; valueIndex < len(queue.values) && valueIndex-shift < len(previousValues); valueIndex++ {
+			if queue.values[valueIndex] != previousValues[valueIndex-shift] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// getSamples converts the given number of most recent entries of the sample arrays (most recent
+// sample first) into timestamped samples (oldest sample first). The most recent sample is stamped
+// with the given timestamp and the preceding ones are stamped backwards using the sampling interval.
+func (syncGroup *onlineMonitorSyncGroup) getSamples(latest time.Time, count int) []*onlineMonitorSample {
+	samplingInterval := syncGroup.getSamplingInterval()
+	queues := syncGroup.getQueues()
+	sampleCount := min(count, syncGroup.getSampleCount())
+	samples := make([]*onlineMonitorSample, 0, max(sampleCount, 0))
+	for sampleIndex := sampleCount - 1; sampleIndex >= 0; sampleIndex-- {
+		timestamp := latest.Add(-time.Duration(sampleIndex) * samplingInterval)
+		fields := make(map[string]interface{})
+		var downstream, upstream int64
+		for _, queue := range queues {
+			if sampleIndex < len(queue.values) {
+				fields[queue.key] = queue.values[sampleIndex]
+				if queue.upstream {
+					upstream += queue.values[sampleIndex]
+				} else {
+					downstream += queue.values[sampleIndex]
+				}
+			}
+		}
+		fields["downstream_bps"] = downstream
+		fields["upstream_bps"] = upstream
+		if syncGroup.DownstreamMax > 0 {
+			fields["downstream_max_bps"] = syncGroup.DownstreamMax
+		}
+		if syncGroup.UpstreamMax > 0 {
+			fields["upstream_max_bps"] = syncGroup.UpstreamMax
+		}
+		samples = append(samples, &onlineMonitorSample{timestamp: timestamp, fields: fields})
+	}
+	return samples
+}
@@ -0,0 +1,73 @@
+// onlinemonitor_test.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testOnlineMonitor1 = "testdata/onlinemonitor1.json"
+const testOnlineMonitor2 = "testdata/onlinemonitor2.json"
+
+func TestOnlineMonitor1(t *testing.T) {
+	monitor := loadTestOnlineMonitor(t, testOnlineMonitor1)
+	require.Equal(t, 1, len(monitor.Data.SyncGroups))
+	syncGroup := &monitor.Data.SyncGroups[0]
+	require.Equal(t, 5*time.Second, syncGroup.getSamplingInterval())
+	latest := time.Date(2024, 2, 1, 12, 0, 3, 0, time.UTC)
+	samples := syncGroup.getSamples(latest, syncGroup.getSampleCount())
+	require.Equal(t, 5, len(samples))
+	require.Equal(t, time.Date(2024, 2, 1, 11, 59, 43, 0, time.UTC), samples[0].timestamp)
+	require.Equal(t, latest, samples[4].timestamp)
+	require.Equal(t, int64(80000), samples[0].fields["downstream_internet_bps"])
+	require.Equal(t, int64(620000), samples[4].fields["downstream_bps"])
+	require.Equal(t, int64(42305), samples[4].fields["upstream_bps"])
+	require.Equal(t, int64(2000), samples[4].fields["upstream_realtime_bps"])
+	require.Equal(t, int64(5000000), samples[4].fields["upstream_max_bps"])
+	samples = syncGroup.getSamples(latest, 2)
+	require.Equal(t, 2, len(samples))
+	require.Equal(t, latest.Add(-5*time.Second), samples[0].timestamp)
+	require.Equal(t, int64(110000), samples[0].fields["downstream_internet_bps"])
+}
+
+func TestOnlineMonitorNewSampleCount(t *testing.T) {
+	previous := &loadTestOnlineMonitor(t, testOnlineMonitor1).Data.SyncGroups[0]
+	current := &loadTestOnlineMonitor(t, testOnlineMonitor2).Data.SyncGroups[0]
+	for expected := 1; expected <= 3; expected++ {
+		count, overlapping := current.getNewSampleCount(previous, expected)
+		require.True(t, overlapping)
+		require.Equal(t, 2, count)
+	}
+	_, overlapping := current.getNewSampleCount(previous, 0)
+	require.False(t, overlapping)
+	_, overlapping = current.getNewSampleCount(previous, 5)
+	require.False(t, overlapping)
+	count, overlapping := previous.getNewSampleCount(previous, 0)
+	require.True(t, overlapping)
+	require.Equal(t, 0, count)
+	samples := current.getSamples(time.Date(2024, 2, 1, 12, 0, 13, 0, time.UTC), count+2)
+	require.Equal(t, 2, len(samples))
+	require.Equal(t, int64(130000), samples[0].fields["downstream_internet_bps"])
+	require.Equal(t, int64(140000), samples[1].fields["downstream_internet_bps"])
+}
+
+func loadTestOnlineMonitor(t *testing.T, filename string) *onlineMonitor {
+	monitorBytes, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	var monitor onlineMonitor
+
+	err = json.Unmarshal(monitorBytes, &monitor)
+	require.NoError(t, err)
+	return &monitor
+}
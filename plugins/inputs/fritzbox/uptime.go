@@ -0,0 +1,39 @@
+// uptime.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"time"
+)
+
+type uptimeSample struct {
+	timestamp time.Time
+	uptime    uint
+}
+
+// isRestart checks whether a restart occurred since the previous sample (indicated by a
+// decreasing uptime).
+func (sample *uptimeSample) isRestart(previous *uptimeSample) bool {
+	return previous != nil && sample.uptime < previous.uptime
+}
+
+// startTime derives the point in time the uptime started counting.
+func (sample *uptimeSample) startTime() time.Time {
+	return sample.timestamp.Add(-time.Duration(sample.uptime) * time.Second)
+}
+
+// outage estimates the duration of the outage preceding a restart. As the exact point in time
+// the outage began is unknown, the time between the previous sample and the restart is
+// reported (which is an upper bound of the actual outage duration).
+func (sample *uptimeSample) outage(previous *uptimeSample) time.Duration {
+	outage := sample.startTime().Sub(previous.timestamp)
+	if outage < 0 {
+		return 0
+	}
+	return outage
+}
@@ -0,0 +1,30 @@
+// uptime_test.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUptimeSample(t *testing.T) {
+	now := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+	first := &uptimeSample{timestamp: now, uptime: 3600}
+	second := &uptimeSample{timestamp: now.Add(60 * time.Second), uptime: 3660}
+	require.False(t, first.isRestart(nil))
+	require.False(t, second.isRestart(first))
+	restart := &uptimeSample{timestamp: now.Add(120 * time.Second), uptime: 45}
+	require.True(t, restart.isRestart(second))
+	require.Equal(t, now.Add(75*time.Second), restart.startTime())
+	require.Equal(t, 15*time.Second, restart.outage(second))
+	// Restart right after the previous sample
+	restart = &uptimeSample{timestamp: now.Add(120 * time.Second), uptime: 61}
+	require.Equal(t, time.Duration(0), restart.outage(second))
+}
@@ -0,0 +1,58 @@
+// wanthroughput.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"time"
+)
+
+type wanCounterSample struct {
+	timestamp     time.Time
+	bytesSent     uint64
+	bytesReceived uint64
+	uptime        uint
+}
+
+// isReset checks whether the device's counters have been reset since the previous sample
+// (indicated by decreasing counters or a decreasing WAN connection uptime).
+func (sample *wanCounterSample) isReset(previous *wanCounterSample) bool {
+	if sample.bytesSent < previous.bytesSent || sample.bytesReceived < previous.bytesReceived {
+		return true
+	}
+	return sample.uptime > 0 && previous.uptime > 0 && sample.uptime < previous.uptime
+}
+
+// throughput derives the tx and rx throughput (in bits per second) since the previous sample.
+// If there is no usable previous sample (none, counter reset or no time elapsed), false is
+// returned.
+func (sample *wanCounterSample) throughput(previous *wanCounterSample) (float64, float64, bool) {
+	if previous == nil || sample.isReset(previous) {
+		return 0, 0, false
+	}
+	elapsed := sample.timestamp.Sub(previous.timestamp).Seconds()
+	if elapsed <= 0 {
+		return 0, 0, false
+	}
+	tx := float64(sample.bytesSent-previous.bytesSent) * 8 / elapsed
+	rx := float64(sample.bytesReceived-previous.bytesReceived) * 8 / elapsed
+	return tx, rx, true
+}
+
+// linkUtilization computes the link utilization of the given throughput (in bits per second)
+// relative to the given max speed (in bytes per second). The max speed is expected to be one of
+// the X_AVM-DE_UpstreamCurrentMaxSpeed/X_AVM-DE_DownstreamCurrentMaxSpeed values reported by
+// WANCommonInterfaceConfig's GetCommonLinkProperties action. AVM's TR-064 documentation of this
+// service (wancommonifconfigSCPD, see https://avm.de/service/schnittstellen/) specifies these
+// values in bytes per second (in contrast to the Layer1*MaxBitRate values, which are reported in
+// bits per second).
+func linkUtilization(bitsPerSecond float64, maxSpeed uint) float64 {
+	if maxSpeed == 0 {
+		return 0
+	}
+	return bitsPerSecond / (float64(maxSpeed) * 8)
+}
@@ -0,0 +1,63 @@
+// wanthroughput_test.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWANCounterSampleThroughput(t *testing.T) {
+	now := time.Now()
+	previous := &wanCounterSample{timestamp: now, bytesSent: 1000, bytesReceived: 2000, uptime: 100}
+	sample := &wanCounterSample{timestamp: now.Add(10 * time.Second), bytesSent: 11000, bytesReceived: 52000, uptime: 100}
+	tx, rx, valid := sample.throughput(previous)
+	require.True(t, valid)
+	require.Equal(t, 8000.0, tx)
+	require.Equal(t, 40000.0, rx)
+	_, _, valid = sample.throughput(nil)
+	require.False(t, valid)
+	_, _, valid = previous.throughput(previous)
+	require.False(t, valid)
+}
+
+func TestWANCounterSampleReset(t *testing.T) {
+	now := time.Now()
+	previous := &wanCounterSample{timestamp: now, bytesSent: 1000, bytesReceived: 2000, uptime: 100}
+	counterReset := &wanCounterSample{timestamp: now.Add(10 * time.Second), bytesSent: 500, bytesReceived: 3000, uptime: 110}
+	require.True(t, counterReset.isReset(previous))
+	uptimeReset := &wanCounterSample{timestamp: now.Add(10 * time.Second), bytesSent: 5000, bytesReceived: 6000, uptime: 5}
+	require.True(t, uptimeReset.isReset(previous))
+	unknownUptime := &wanCounterSample{timestamp: now.Add(10 * time.Second), bytesSent: 5000, bytesReceived: 6000}
+	require.False(t, unknownUptime.isReset(previous))
+	_, _, valid := uptimeReset.throughput(previous)
+	require.False(t, valid)
+}
+
+func TestLinkUtilization(t *testing.T) {
+	require.Equal(t, 0.5, linkUtilization(4000, 1000))
+	require.Equal(t, 0.0, linkUtilization(4000, 0))
+	// A 100 Mbit/s downstream is reported as 12500000 bytes per second
+	require.Equal(t, 1.0, linkUtilization(100000000, 12500000))
+	require.Equal(t, 0.25, linkUtilization(25000000, 12500000))
+}
+
+func TestLinkUtilizationFromCounters(t *testing.T) {
+	// Downstream max speed as reported by the test device (bytes per second)
+	const downstreamCurrentMaxSpeed = 1711517
+	now := time.Now()
+	previous := &wanCounterSample{timestamp: now, bytesReceived: 1000000, uptime: 100}
+	// Half of the max speed received for 10 seconds
+	sample := &wanCounterSample{timestamp: now.Add(10 * time.Second), bytesReceived: 1000000 + downstreamCurrentMaxSpeed*10/2, uptime: 110}
+	_, rx, valid := sample.throughput(previous)
+	require.True(t, valid)
+	require.InDelta(t, 6846068.0, rx, 0.1)
+	require.InDelta(t, 0.5, linkUtilization(rx, downstreamCurrentMaxSpeed), 0.000001)
+}
@@ -0,0 +1,311 @@
+// websession.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const webSessionInvalidSID = "0000000000000000"
+
+// The device drops idle sessions after 20 minutes; we re-login a little earlier
+const webSessionIdleTimeout = 15 * time.Minute
+
+type webSession struct {
+	webUrl       *url.URL
+	sid          string
+	lastUsed     time.Time
+	blockedUntil time.Time
+}
+
+type webSessionInfo struct {
+	SID       string `xml:"SID"`
+	Challenge string `xml:"Challenge"`
+	BlockTime int    `xml:"BlockTime"`
+}
+
+func (session *webSession) isValid(now time.Time) bool {
+	return session.sid != "" && now.Sub(session.lastUsed) < webSessionIdleTimeout
+}
+
+func (session *webSession) invalidate() {
+	session.sid = ""
+}
+
+func (session *webSession) block(now time.Time, blockTime int) {
+	if blockTime > 0 {
+		session.blockedUntil = now.Add(time.Duration(blockTime) * time.Second)
+	}
+}
+
+func getWebUrl(baseUrl *url.URL) *url.URL {
+	webUrl := &url.URL{Scheme: baseUrl.Scheme, Host: baseUrl.Host, Path: "/"}
+	host := baseUrl.Hostname()
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	switch baseUrl.Port() {
+	case "49000":
+		webUrl.Scheme = "http"
+		webUrl.Host = host
+	case "49443":
+		webUrl.Scheme = "https"
+		webUrl.Host = host
+	}
+	return webUrl
+}
+
+func (plugin *FritzBox) getWebSession(deviceInfo *deviceInfo) *webSession {
+	if deviceInfo.webSession == nil {
+		deviceInfo.webSession = &webSession{webUrl: getWebUrl(deviceInfo.BaseUrl)}
+	}
+	return deviceInfo.webSession
+}
+
+// withWebSession invokes the given web request function with a valid session id. If the
+// function reports the session id as rejected, the session is re-established once.
+func (plugin *FritzBox) withWebSession(deviceInfo *deviceInfo, request func(webUrl *url.URL, sid string) (bool, error)) error {
+	session := plugin.getWebSession(deviceInfo)
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now()
+		if !session.isValid(now) {
+			if attempt > 0 && plugin.Debug {
+				plugin.Log.Infof("Web session for %s expired; logging in again", session.webUrl.Host)
+			}
+			err := plugin.webSessionLogin(deviceInfo, session, now)
+			if err != nil {
+				return err
+			}
+		}
+		valid, err := request(session.webUrl, session.sid)
+		if err != nil {
+			return err
+		}
+		if valid {
+			session.lastUsed = time.Now()
+			return nil
+		}
+		session.invalidate()
+	}
+	return fmt.Errorf("web session for %s rejected", session.webUrl.Host)
+}
+
+func (plugin *FritzBox) webSessionLogin(deviceInfo *deviceInfo, session *webSession, now time.Time) error {
+	if now.Before(session.blockedUntil) {
+		return fmt.Errorf("login to %s blocked until %s", session.webUrl.Host, session.blockedUntil.Format(time.RFC3339))
+	}
+	loginUrl := session.webUrl.ResolveReference(&url.URL{Path: "login_sid.lua", RawQuery: "version=2"})
+	if plugin.Debug {
+		plugin.Log.Infof("Requesting login challenge from: %s", loginUrl)
+	}
+	client := plugin.getClient()
+	response, err := client.Get(loginUrl.String())
+	if err != nil {
+		return err
+	}
+	var challengeInfo webSessionInfo
+	err = decodeWebXML(response, &challengeInfo)
+	if err != nil {
+		return err
+	}
+	if challengeInfo.BlockTime > 0 {
+		session.block(now, challengeInfo.BlockTime)
+		return fmt.Errorf("login to %s blocked for %d seconds", session.webUrl.Host, challengeInfo.BlockTime)
+	}
+	challengeResponse, err := calculateChallengeResponse(challengeInfo.Challenge, deviceInfo.Password)
+	if err != nil {
+		return err
+	}
+	response, err = client.PostForm(loginUrl.String(), url.Values{"username": {deviceInfo.Login}, "response": {challengeResponse}})
+	if err != nil {
+		return err
+	}
+	var sessionInfo webSessionInfo
+	err = decodeWebXML(response, &sessionInfo)
+	if err != nil {
+		return err
+	}
+	if sessionInfo.SID == "" || sessionInfo.SID == webSessionInvalidSID {
+		// A failed login is answered with the block time to wait before the next attempt
+		session.block(now, sessionInfo.BlockTime)
+		return fmt.Errorf("login to %s failed", session.webUrl.Host)
+	}
+	session.sid = sessionInfo.SID
+	session.lastUsed = now
+	return nil
+}
+
+func (plugin *FritzBox) webSessionLogout(deviceInfo *deviceInfo) error {
+	session := deviceInfo.webSession
+	if session == nil || session.sid == "" {
+		return nil
+	}
+	logoutUrl := session.webUrl.ResolveReference(&url.URL{Path: "login_sid.lua", RawQuery: url.Values{"version": {"2"}, "logout": {"1"}, "sid": {session.sid}}.Encode()})
+	if plugin.Debug {
+		plugin.Log.Infof("Logging out from: %s", session.webUrl.Host)
+	}
+	session.invalidate()
+	response, err := plugin.getClient().Get(logoutUrl.String())
+	if err != nil {
+		return err
+	}
+	var sessionInfo webSessionInfo
+	return decodeWebXML(response, &sessionInfo)
+}
+
+func (plugin *FritzBox) fetchWebData(deviceInfo *deviceInfo, page string, v interface{}) error {
+	return plugin.withWebSession(deviceInfo, func(webUrl *url.URL, sid string) (bool, error) {
+		return plugin.postWebData(webUrl, sid, page, v)
+	})
+}
+
+func (plugin *FritzBox) postWebData(webUrl *url.URL, sid string, page string, v interface{}) (bool, error) {
+	dataUrl := webUrl.ResolveReference(&url.URL{Path: "data.lua"})
+	if plugin.Debug {
+		plugin.Log.Infof("Fetching web data page %s from: %s", page, dataUrl)
+	}
+	response, err := plugin.getClient().PostForm(dataUrl.String(), url.Values{
+		"xhr":         {"1"},
+		"sid":         {sid},
+		"lang":        {"en"},
+		"page":        {page},
+		"xhrId":       {"all"},
+		"useajax":     {"1"},
+		"no_sidrenew": {""},
+	})
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusForbidden || response.StatusCode == http.StatusUnauthorized {
+		return false, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status code %d for %s", response.StatusCode, dataUrl)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return false, err
+	}
+	// An expired session is answered with the login page (or at least an invalid SID)
+	session := struct {
+		SID string `json:"sid"`
+	}{}
+	if json.Unmarshal(body, &session) != nil || session.SID == "" || session.SID == webSessionInvalidSID {
+		return false, nil
+	}
+	return true, json.Unmarshal(body, v)
+}
+
+func (plugin *FritzBox) fetchWebXML(deviceInfo *deviceInfo, path string, query url.Values, v interface{}) error {
+	return plugin.withWebSession(deviceInfo, func(webUrl *url.URL, sid string) (bool, error) {
+		return plugin.getWebXML(webUrl, sid, path, query, v)
+	})
+}
+
+func (plugin *FritzBox) getWebXML(webUrl *url.URL, sid string, path string, query url.Values, v interface{}) (bool, error) {
+	sidQuery := url.Values{"sid": {sid}}
+	for key, values := range query {
+		sidQuery[key] = values
+	}
+	xmlUrl := webUrl.ResolveReference(&url.URL{Path: path, RawQuery: sidQuery.Encode()})
+	if plugin.Debug {
+		plugin.Log.Infof("Fetching web XML from: %s", webUrl.ResolveReference(&url.URL{Path: path, RawQuery: query.Encode()}))
+	}
+	response, err := plugin.getClient().Get(xmlUrl.String())
+	if err != nil {
+		return false, err
+	}
+	// An invalid or expired session is answered with status 403
+	if response.StatusCode == http.StatusForbidden || response.StatusCode == http.StatusUnauthorized {
+		response.Body.Close()
+		return false, nil
+	}
+	return true, decodeWebXML(response, v)
+}
+
+// errWebPageNotFound indicates a web page not provided by the device (e.g. the smart home
+// interface on devices without DECT base station).
+var errWebPageNotFound = errors.New("web page not found")
+
+func decodeWebXML(response *http.Response, v interface{}) error {
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", errWebPageNotFound, response.Request.URL.Path)
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d for %s", response.StatusCode, response.Request.URL.Path)
+	}
+	return xml.NewDecoder(response.Body).Decode(v)
+}
+
+func calculateChallengeResponse(challenge string, password string) (string, error) {
+	if strings.HasPrefix(challenge, "2$") {
+		return calculatePBKDF2ChallengeResponse(challenge, password)
+	}
+	return calculateMD5ChallengeResponse(challenge, password), nil
+}
+
+func calculatePBKDF2ChallengeResponse(challenge string, password string) (string, error) {
+	challengeParts := strings.Split(challenge, "$")
+	if len(challengeParts) != 5 {
+		return "", errors.New("invalid PBKDF2 challenge")
+	}
+	iterations1, err := strconv.Atoi(challengeParts[1])
+	if err != nil {
+		return "", err
+	}
+	salt1, err := hex.DecodeString(challengeParts[2])
+	if err != nil {
+		return "", err
+	}
+	iterations2, err := strconv.Atoi(challengeParts[3])
+	if err != nil {
+		return "", err
+	}
+	salt2, err := hex.DecodeString(challengeParts[4])
+	if err != nil {
+		return "", err
+	}
+	hash1 := pbkdf2.Key([]byte(password), salt1, iterations1, sha256.Size, sha256.New)
+	hash2 := pbkdf2.Key(hash1, salt2, iterations2, sha256.Size, sha256.New)
+	return challengeParts[4] + "$" + hex.EncodeToString(hash2), nil
+}
+
+func calculateMD5ChallengeResponse(challenge string, password string) string {
+	// Legacy MD5 response is calculated on the UTF-16LE encoded challenge-password string
+	// (with characters beyond ISO-8859-1 replaced by '.')
+	challengePassword := []rune(challenge + "-" + password)
+	for runeIndex, r := range challengePassword {
+		if r > 255 {
+			challengePassword[runeIndex] = '.'
+		}
+	}
+	encoded := utf16.Encode(challengePassword)
+	challengePasswordBytes := make([]byte, 0, 2*len(encoded))
+	for _, c := range encoded {
+		challengePasswordBytes = append(challengePasswordBytes, byte(c), byte(c>>8))
+	}
+	hash := md5.Sum(challengePasswordBytes)
+	return challenge + "-" + hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,94 @@
+// websession_test.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetWebUrl(t *testing.T) {
+	for _, test := range [][2]string{
+		{"http://fritz.box:49000", "http://fritz.box/"},
+		{"https://fritz.box:49443", "https://fritz.box/"},
+		{"http://[fd00::1]:49000", "http://[fd00::1]/"},
+		{"http://127.0.0.1:8080", "http://127.0.0.1:8080/"},
+	} {
+		baseUrl, err := url.Parse(test[0])
+		require.NoError(t, err)
+		require.Equal(t, test[1], getWebUrl(baseUrl).String())
+	}
+}
+
+func TestCalculateChallengeResponse(t *testing.T) {
+	response, err := calculateChallengeResponse("2$10000$5A1711$2000$5A1722", "1example!")
+	require.NoError(t, err)
+	require.Equal(t, "5A1722$1798a1672bca7c6463d6b245f82b53703b0f50813401b03e4045a5861e689adb", response)
+	response, err = calculateChallengeResponse("1234567z", "äbc")
+	require.NoError(t, err)
+	require.Equal(t, "1234567z-9e224a41eeefa284df7bb0f26c2913e2", response)
+	_, err = calculateChallengeResponse("2$10000$5A1711", "1example!")
+	require.Error(t, err)
+}
+
+func TestWebSession(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewFritzBox()
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	deviceInfo, err := plugin.fetchDeviceInfo(testServer.URL, "user", "secret")
+	require.NoError(t, err)
+
+	var spectrum dslSpectrum
+
+	require.NoError(t, plugin.fetchWebData(deviceInfo, "dslSpectrum", &spectrum))
+	require.Equal(t, 1, len(spectrum.Data.Ports))
+	require.Equal(t, 1, testServerHandler.WebLogins)
+	require.NoError(t, plugin.fetchWebData(deviceInfo, "dslSpectrum", &spectrum))
+	require.Equal(t, 1, testServerHandler.WebLogins)
+
+	// Idle sessions are re-established
+	deviceInfo.webSession.lastUsed = time.Now().Add(-webSessionIdleTimeout)
+	require.NoError(t, plugin.fetchWebData(deviceInfo, "dslSpectrum", &spectrum))
+	require.Equal(t, 2, testServerHandler.WebLogins)
+
+	plugin.deviceInfos[testServer.URL] = deviceInfo
+	plugin.Stop()
+	require.Equal(t, 1, testServerHandler.WebLogouts)
+	require.Equal(t, "", deviceInfo.webSession.sid)
+	plugin.Stop()
+	require.Equal(t, 1, testServerHandler.WebLogouts)
+}
+
+func TestWebSessionBlockTime(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true, WebBlockTime: 60}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewFritzBox()
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	deviceInfo, err := plugin.fetchDeviceInfo(testServer.URL, "user", "secret")
+	require.NoError(t, err)
+
+	var spectrum dslSpectrum
+
+	require.ErrorContains(t, plugin.fetchWebData(deviceInfo, "dslSpectrum", &spectrum), "blocked for 60 seconds")
+	require.True(t, deviceInfo.webSession.blockedUntil.After(time.Now()))
+	testServerHandler.WebBlockTime = 0
+	require.ErrorContains(t, plugin.fetchWebData(deviceInfo, "dslSpectrum", &spectrum), "blocked until")
+	require.Equal(t, 0, testServerHandler.WebLogins)
+	deviceInfo.webSession.blockedUntil = time.Now()
+	require.NoError(t, plugin.fetchWebData(deviceInfo, "dslSpectrum", &spectrum))
+	require.Equal(t, 1, testServerHandler.WebLogins)
+}
@@ -0,0 +1,29 @@
+// wlanneighbourlist.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+type wlanNeighbourList struct {
+	Items []wlanNeighbourListItem `xml:"Item"`
+}
+
+func (neighbourList *wlanNeighbourList) countChannel(channel string) int {
+	count := 0
+	for _, item := range neighbourList.Items {
+		if item.Channel == channel {
+			count++
+		}
+	}
+	return count
+}
+
+type wlanNeighbourListItem struct {
+	SSID    string `xml:"SSID"`
+	BSSID   string `xml:"BSSID"`
+	Channel string `xml:"Channel"`
+	RSSI    int    `xml:"RSSI"`
+}
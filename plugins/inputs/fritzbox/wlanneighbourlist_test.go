@@ -0,0 +1,39 @@
+// wlanneighbourlist_test.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package fritzbox
+
+import (
+	"encoding/xml"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testWLANNeighbourList1 = "testdata/wlanneighbourlist1.xml"
+
+func TestWLANNeighbourList1(t *testing.T) {
+	neighbourList := loadTestWLANNeighbourList(t, testWLANNeighbourList1)
+	require.Equal(t, 3, len(neighbourList.Items))
+	require.Equal(t, "Office1", neighbourList.Items[0].SSID)
+	require.Equal(t, -62, neighbourList.Items[0].RSSI)
+	require.Equal(t, 2, neighbourList.countChannel("36"))
+	require.Equal(t, 1, neighbourList.countChannel("6"))
+	require.Equal(t, 0, neighbourList.countChannel("1"))
+}
+
+func loadTestWLANNeighbourList(t *testing.T, filename string) *wlanNeighbourList {
+	neighbourListBytes, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	var neighbourList wlanNeighbourList
+
+	err = xml.Unmarshal(neighbourListBytes, &neighbourList)
+	require.NoError(t, err)
+	return &neighbourList
+}